@@ -2,4 +2,7 @@ module plots
 
 go 1.14
 
-require gonum.org/v1/plot v0.8.0
+require (
+	github.com/mattn/go-sqlite3 v1.14.5
+	gonum.org/v1/plot v0.8.0
+)
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"container/list"
+	"os"
+	"plots/parser"
+	"sync"
+	"time"
+)
+
+// Keep the parsed data files in memory (enabled by the commands drawing several diagrams of the same files)
+var CACHE = false
+
+// Maximum number of messages of the parsed data files kept in memory (option -cache)
+// the least recently used files are evicted beyond
+var CACHESIZE = 20000000
+
+// Parsed timestamps of a data file, valid while the file is not modified
+type parsedFile struct {
+	name     string
+	modTime  time.Time
+	size     int64
+	ts1, ts2 []int64
+	err      error
+	n        int           // number of messages counted in the cache size, 0 while parsing
+	ready    chan struct{} // closed when the file is parsed
+}
+
+var (
+	cacheMu  sync.Mutex
+	cache    = make(map[string]*list.Element)
+	lru      = list.New() // most recently used first
+	cachedNb int          // number of messages in the cache
+)
+
+// Remove the entry of the cache, cacheMu must be locked
+func evict(e *list.Element) {
+	pf := lru.Remove(e).(*parsedFile)
+	delete(cache, pf.name)
+	cachedNb -= pf.n
+}
+
+// Parse the data file with parser.ParseData, or return its cached timestamps if CACHE is set
+// a file requested while being parsed is parsed once
+// the returned slices are shared and must not be modified
+func parseData(filename string) ([]int64, []int64, error) {
+	if !CACHE {
+		return parser.ParseData(filename)
+	}
+	info, err := os.Stat(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	cacheMu.Lock()
+	if e, found := cache[filename]; found {
+		pf := e.Value.(*parsedFile)
+		if pf.modTime.Equal(info.ModTime()) && pf.size == info.Size() {
+			lru.MoveToFront(e)
+			cacheMu.Unlock()
+			<-pf.ready
+			return pf.ts1, pf.ts2, pf.err
+		}
+		evict(e)
+	}
+	pf := &parsedFile{name: filename, modTime: info.ModTime(), size: info.Size(), ready: make(chan struct{})}
+	e := lru.PushFront(pf)
+	cache[filename] = e
+	cacheMu.Unlock()
+
+	ts1, ts2, err := parser.ParseData(filename)
+	pf.ts1, pf.ts2, pf.err = ts1, ts2, err
+	cacheMu.Lock()
+	if cache[filename] == e {
+		if err != nil || len(ts1) > CACHESIZE {
+			// not kept, the next request parses the file again
+			evict(e)
+		} else {
+			pf.n = len(ts1)
+			cachedNb += pf.n
+			for cachedNb > CACHESIZE {
+				evict(lru.Back())
+			}
+		}
+	}
+	cacheMu.Unlock()
+	close(pf.ready)
+	return ts1, ts2, err
+}
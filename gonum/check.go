@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"plots/stats"
+)
+
+// Maximum relative increase of the mean latency before a regression (option -tolmean)
+var TOLMEAN = 0.1
+
+// Maximum relative increase of the p99 latency before a regression (option -tolp99)
+var TOLP99 = 0.2
+
+// Maximum relative decrease of the throughput before a regression (option -tolthr)
+var TOLTHR = 0.1
+
+// Significance level of the Welch test of the increase of the mean latency (option -alpha)
+var ALPHA = 0.01
+
+// Stats of a data file stored in a baseline
+type RunStats struct {
+	Abscissa   string  `json:"abscissa"`
+	N          int     `json:"n"`          // number of latencies
+	ESS        float64 `json:"ess"`        // effective sample size of the latencies, corrected for their autocorrelation
+	Mean       float64 `json:"mean"`       // mean latency (ms)
+	Sdev       float64 `json:"sdev"`       // standard deviation of the latencies (ms)
+	Median     float64 `json:"median"`     // median of the latencies (ms)
+	P95        float64 `json:"p95"`        // 95th percentile of the latencies (ms)
+	P99        float64 `json:"p99"`        // 99th percentile of the latencies (ms)
+	P999       float64 `json:"p999"`       // 99.9th percentile of the latencies (ms)
+	Throughput float64 `json:"throughput"` // throughput (Mb/s)
+	MsgPerSec  float64 `json:"msgPerSec"`  // number of messages per second
+}
+
+// Options of the analysis changing the stats of a baseline
+type BaselineOptions struct {
+	Outliers string  `json:"outliers,omitempty"` // option -out
+	OutK     float64 `json:"outk,omitempty"`     // option -outk
+	HampelW  int     `json:"hw,omitempty"`       // option -hw
+	Exclude  bool    `json:"exclude,omitempty"`  // option -exclude
+	Skew     bool    `json:"skew,omitempty"`     // option -skew
+}
+
+// Baseline of a config : the stats of its files
+type Baseline struct {
+	Config  string          `json:"config"`
+	Options BaselineOptions `json:"options"`
+	Files   []RunStats      `json:"files"`
+}
+
+// Return the current options changing the stats, the options without effect are left empty
+func baselineOptions() BaselineOptions {
+	o := BaselineOptions{Skew: SKEW}
+	if OUTLIERS != "" {
+		o.Outliers, o.OutK, o.Exclude = OUTLIERS, OUTK, EXCLUDE
+		if OUTLIERS == stats.OutHampel.String() {
+			o.HampelW = HAMPELW
+		}
+	}
+	return o
+}
+
+// Compute the stats of each file of the config
+func computeBaseline(ctx context.Context, c Config) (Baseline, error) {
+	b := Baseline{Config: c.name, Options: baselineOptions(), Files: make([]RunStats, len(c.files))}
+	sizes, err := msgSizes(c)
+	if err != nil {
+		return b, err
+	}
+	trput, err := computeThoughputFiles(ctx, c.files, sizes, c.nbPtsDiscard)
+	if err != nil {
+		return b, err
+	}
+	msgs, err := computeNbMsgPerSecFiles(ctx, c.files, c.nbPtsDiscard)
+	if err != nil {
+		return b, err
+	}
+	for i, f := range c.files {
+		if err := ctx.Err(); err != nil {
+			return b, err
+		}
+		fvalues, err := parseFile(f)
+		if err != nil {
+			return b, err
+		}
+		data, _, err := cleanData(fvalues[c.nbPtsDiscard:], c.nbPtsDiscard, filepath.Base(f))
+		if err != nil {
+			return b, err
+		}
+		mean, _, sdev, _, _, err := stats.Moments(data)
+		if err != nil {
+			return b, err
+		}
+		ess, err := stats.EffectiveSampleSize(data, MAXLAG)
+		if err != nil {
+			ess = float64(len(data))
+		}
+		b.Files[i] = RunStats{Abscissa: c.abscis[i], N: len(data), ESS: ess, Mean: mean, Sdev: sdev,
+			Median: stats.Quantile(data, 0.5), P95: stats.Quantile(data, 0.95), P99: stats.Quantile(data, 0.99),
+			P999: stats.Quantile(data, 0.999), Throughput: trput[i], MsgPerSec: msgs[i]}
+	}
+	return b, nil
+}
+
+// Save the stats of a config (computed by computeBaseline) as a JSON baseline
+func saveBaseline(b Baseline, filename string) error {
+	buf, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, buf, 0644)
+}
+
+// Read a JSON baseline
+func loadBaseline(filename string) (Baseline, error) {
+	var b Baseline
+	buf, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return b, err
+	}
+	err = json.Unmarshal(buf, &b)
+	return b, err
+}
+
+// Relative change from old to new
+func relChange(old, new float64) float64 {
+	if old == 0 {
+		if new == 0 {
+			return 0
+		}
+		return math.Inf(1)
+	}
+	return (new - old) / math.Abs(old)
+}
+
+// Compare the stats of the config (computed by computeBaseline) with the baseline and print a pass/fail table
+// a mean latency is a regression if it increased by more than TOLMEAN and the increase is significant at the level ALPHA
+// (Welch test with the effective sample sizes), a p99 if it increased by more than TOLP99, a throughput if it decreased
+// by more than TOLTHR, an abscissa of the baseline missing in the config is a failure
+// Returns false if there is a regression
+func checkBaseline(c Config, cur Baseline, filename string) (bool, error) {
+	base, err := loadBaseline(filename)
+	if err != nil {
+		return false, err
+	}
+	if base.Config != c.name {
+		return false, errors.New("The baseline " + filename + " is for the config " + base.Config + " not " + c.name)
+	}
+	if base.Options != cur.Options {
+		return false, fmt.Errorf("The baseline %s was computed with the options %+v, not %+v", filename, base.Options, cur.Options)
+	}
+	old := make(map[string]RunStats)
+	for _, s := range base.Files {
+		old[s.Abscissa] = s
+	}
+	ok := true
+	fmt.Printf("%-10s %-11s %12s %12s %9s %9s  %s\n", c.xlabel, "metric", "baseline", "current", "change", "p-value", "status")
+	for _, s := range cur.Files {
+		o, found := old[s.Abscissa]
+		if !found {
+			fmt.Printf("%-10s %-11s %12s %12s %9s %9s  %s\n", s.Abscissa, "all", "-", "-", "-", "-", "NEW")
+			continue
+		}
+		delete(old, s.Abscissa)
+		// mean latency : tolerance and Welch test
+		change := relChange(o.Mean, s.Mean)
+		_, _, pval, err := stats.Welch(o.Mean, o.Sdev, effectiveSize(o), s.Mean, s.Sdev, effectiveSize(s))
+		if err != nil {
+			pval = math.NaN()
+		}
+		fail := change > TOLMEAN && !(pval >= ALPHA)
+		ok = printCheck(s.Abscissa, "mean(ms)", o.Mean, s.Mean, change, pval, fail) && ok
+		// p99 latency : tolerance
+		change = relChange(o.P99, s.P99)
+		ok = printCheck(s.Abscissa, "p99(ms)", o.P99, s.P99, change, math.NaN(), change > TOLP99) && ok
+		// throughput : tolerance
+		change = relChange(o.Throughput, s.Throughput)
+		ok = printCheck(s.Abscissa, "trput(Mb/s)", o.Throughput, s.Throughput, change, math.NaN(), -change > TOLTHR) && ok
+	}
+	// abscissas of the baseline without data file in the run
+	for _, s := range base.Files {
+		if _, missing := old[s.Abscissa]; missing {
+			fmt.Printf("%-10s %-11s %12s %12s %9s %9s  %s\n", s.Abscissa, "all", "-", "-", "-", "-", "MISSING")
+			ok = false
+		}
+	}
+	return ok, nil
+}
+
+// Return the effective sample size of the stats, their number of latencies for the baselines saved without it
+func effectiveSize(s RunStats) float64 {
+	if s.ESS > 0 {
+		return s.ESS
+	}
+	return float64(s.N)
+}
+
+// Print a line of the check table, return false if it failed
+func printCheck(abscissa, metric string, old, new, change, pval float64, fail bool) bool {
+	status := "PASS"
+	if fail {
+		status = "FAIL"
+	}
+	p := "-"
+	if !math.IsNaN(pval) {
+		p = fmt.Sprintf("%.2e", pval)
+	}
+	fmt.Printf("%-10s %-11s %12.4e %12.4e %+8.1f%% %9s  %s\n", abscissa, metric, old, new, 100*change, p, status)
+	return !fail
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Save the baseline in a temporary file and return its name
+func tempBaseline(b Baseline, t *testing.T) string {
+	dir, err := ioutil.TempDir("", "plots")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	filename := filepath.Join(dir, "base.json")
+	if err = saveBaseline(b, filename); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+// An abscissa of the baseline missing in the run fails the check, a new one does not
+func TestCheckBaselineMissing(t *testing.T) {
+	c := Config{name: "c", xlabel: "x"}
+	s := RunStats{N: 1000, ESS: 100, Mean: 10, Sdev: 2, P99: 20, Throughput: 100}
+	a, b, d := s, s, s
+	a.Abscissa, b.Abscissa, d.Abscissa = "1", "2", "3"
+	filename := tempBaseline(Baseline{Config: "c", Files: []RunStats{a, b}}, t)
+	ok, err := checkBaseline(c, Baseline{Config: "c", Files: []RunStats{a, b, d}}, filename)
+	if err != nil || !ok {
+		t.Errorf("Check failed with a new abscissa: %v", err)
+	}
+	ok, err = checkBaseline(c, Baseline{Config: "c", Files: []RunStats{a}}, filename)
+	if err != nil || ok {
+		t.Errorf("Check passed with a missing abscissa: %v", err)
+	}
+}
+
+// A baseline saved with other options is refused
+func TestCheckBaselineOptions(t *testing.T) {
+	c := Config{name: "c", xlabel: "x"}
+	filename := tempBaseline(Baseline{Config: "c", Options: BaselineOptions{Outliers: "iqr", OutK: 3}}, t)
+	if _, err := checkBaseline(c, Baseline{Config: "c"}, filename); err == nil {
+		t.Error("No error for a baseline saved with other options")
+	}
+	if _, err := checkBaseline(Config{name: "other"}, Baseline{Config: "other"}, filename); err == nil {
+		t.Error("No error for a baseline of another config")
+	}
+}
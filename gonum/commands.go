@@ -0,0 +1,458 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"plots/plotfunc"
+	"plots/sliceutil"
+	"plots/stats"
+	"strings"
+)
+
+// Create the option set of a command
+// args describes the arguments following the options in the help
+func newFlags(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: plots %s [options]%s\n\nOptions:\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// Parse the options of a command and check their values
+func parseFlags(fs *flag.FlagSet, args []string, checks ...func() error) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	for _, check := range checks {
+		if err := check(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Add the options of the analysis and of the drawings, return the function checking their values
+func analysisFlags(fs *flag.FlagSet) func() error {
+	fs.IntVar(&NVAL, "window", NVAL, "Window interval when using the drawSlide")
+	fs.IntVar(&TW, "tw", TW, "Width in ms of the time windows of the sliding percentiles (0 = windows of -window messages)")
+	fs.IntVar(&NCOL, "cols", NCOL, "Number of columns of the histograms")
+	fs.BoolVar(&SKEW, "skew", SKEW, "Correct the latencies for the clock offset and drift between the hosts (minimum delay envelope), the latencies become the delays above the minimum delay")
+	fs.Float64Var(&MAXLAT, "maxlat", MAXLAT, "Latencies in ms above which a warning is printed")
+	fs.Float64Var(&CO, "co", CO, "Intended interval in ms between the sent messages, corrects the percentiles and cumulative distributions for the coordinated omission (0 = no correction)")
+	fs.StringVar(&BINS, "bins", BINS, "Rule of the number of columns of the histograms: fd (Freedman-Diaconis), scott or sturges (default -cols columns)")
+	fs.BoolVar(&LOGBINS, "logbins", LOGBINS, "Use logarithmically spaced columns in the histograms")
+	fs.BoolVar(&DIST, "dist", DIST, "Fit the normal, log-normal, gamma, Weibull, exponential and shifted distributions on the histograms and draw the best one (default the normal distribution of the moments)")
+	fs.StringVar(&KERNEL, "kde", KERNEL, "Draw a kernel density estimation on the histograms with the kernel gauss or epanechnikov (also used by the violins)")
+	fs.StringVar(&BW, "bw", BW, "Bandwidth rule of the kernel density estimations: silverman or sj (Sheather-Jones)")
+	fs.IntVar(&BUCKET, "bucket", BUCKET, "Width in ms of the time buckets when drawing the throughput over time or the heat maps")
+	fs.BoolVar(&PRINT, "print", PRINT, "Print the moments of the distribution while drawing")
+	fs.BoolVar(&LOGX, "logx", LOGX, "Use a log scale for the latency axis of the cumulative distributions")
+	fs.BoolVar(&LOGY, "logy", LOGY, "Use a log scale for the probability axis of the cumulative distributions")
+	fs.StringVar(&NLFIT, "fit", NLFIT, "Nonlinear model fitted on the throughput and msg / s plots: saturation (a (1 - exp(-x / b))) or inverse (a + b / x)")
+	fs.IntVar(&POLYDEG, "poly", POLYDEG, "Maximum degree of the polynomial fits of the means with errors (0 = no fit)")
+	crit := fs.String("crit", CRIT.String(), "Criterion selecting the degree of the polynomial fits: aic, adjr2 or cv")
+	fs.StringVar(&ERRS, "errs", ERRS, "Errors of the means: stderr, ess (stderr corrected by the effective sample size), batch (batch means), boot (bootstrap) or block (block bootstrap)")
+	fs.StringVar(&OUTLIERS, "out", OUTLIERS, "Detect the outliers with the method iqr (Tukey's fences), mad (median absolute deviation) or hampel (Hampel filter)")
+	fs.Float64Var(&OUTK, "outk", OUTK, "Threshold of the outlier detection, in IQRs beyond the quartiles or in MADs from the median")
+	fs.IntVar(&HAMPELW, "hw", HAMPELW, "Half width of the sliding window of the Hampel filter")
+	fs.BoolVar(&EXCLUDE, "exclude", EXCLUDE, "Exclude the outliers from the moments and the fits")
+	fs.StringVar(&CHANGES, "cp", CHANGES, "Detect the change points of the latencies and the knees of the means with errors with the method pelt, binseg or cusum")
+	fs.Float64Var(&CPPEN, "cppen", CPPEN, "Penalty per change point of the pelt and binseg methods (0 = 2 ln(n))")
+	fs.IntVar(&CPMIN, "cpmin", CPMIN, "Minimum number of messages between two change points of the latencies")
+	fs.IntVar(&DT, "dt", DT, "Time step in ms of the uniform grid used by the spectral analysis")
+	fs.IntVar(&MAXLAG, "maxlag", MAXLAG, "Maximum lag of the autocorrelation functions and of the effective sample sizes")
+	fs.IntVar(&NBOOT, "nboot", NBOOT, "Number of bootstrap resamples")
+	fs.IntVar(&CACHESIZE, "cache", CACHESIZE, "Maximum number of messages of the parsed data files kept in memory, the least recently used files are evicted beyond")
+	slo := fs.String("slo", "", "Comma separated latency thresholds (ms) to mark on the cumulative distributions")
+	fs.IntVar(&JOBS, "j", JOBS, "Maximum number of diagrams drawn in parallel")
+	fs.BoolVar(&KEEPGOING, "keep-going", KEEPGOING, "Go on with the other diagrams and files after a failure, else stop at the first one (the failures are listed at the end)")
+
+	return func() error {
+		if JOBS < 1 {
+			return fmt.Errorf("the number of parallel diagrams should be positive. Found %d", JOBS)
+		}
+		if NVAL < 2 {
+			return fmt.Errorf("the window interval should be greater than 1. Found %d", NVAL)
+		}
+		if NCOL < 2 {
+			return fmt.Errorf("the histogram number of columns should be greater than 1. Found %d", NCOL)
+		}
+		if BUCKET < 1 {
+			return fmt.Errorf("the time bucket should be at least 1 ms. Found %d", BUCKET)
+		}
+		if NLFIT != "" && NLFIT != "saturation" && NLFIT != "inverse" {
+			return errors.New("unknown nonlinear model " + NLFIT)
+		}
+		switch ERRS {
+		case "stderr", "ess", "batch", "boot", "block":
+		default:
+			return errors.New("unknown errors " + ERRS)
+		}
+		switch OUTLIERS {
+		case "", stats.OutIQR.String(), stats.OutMAD.String(), stats.OutHampel.String():
+		default:
+			return errors.New("unknown outlier detection method " + OUTLIERS)
+		}
+		if OUTK <= 0 || HAMPELW < 1 {
+			return fmt.Errorf("the outlier threshold and the Hampel half width should be positive. Found %g %d", OUTK, HAMPELW)
+		}
+		if err := (Histo{bins: BINS, kernel: KERNEL, bw: BW}).check(); err != nil {
+			return err
+		}
+		if CO < 0 {
+			return fmt.Errorf("the interval between messages should be positive. Found %g", CO)
+		}
+		if TW < 0 {
+			return fmt.Errorf("the time window should be positive. Found %d", TW)
+		}
+		switch CHANGES {
+		case "", stats.CpPELT.String(), stats.CpBinSeg.String(), stats.CpCUSUM.String():
+		default:
+			return errors.New("unknown change point detection method " + CHANGES)
+		}
+		if CPPEN < 0 || CPMIN < 1 {
+			return fmt.Errorf("the change point penalty and minimum segment should be positive. Found %g %d", CPPEN, CPMIN)
+		}
+		if DT < 1 {
+			return fmt.Errorf("the time step should be at least 1 ms. Found %d", DT)
+		}
+		if MAXLAG < 1 {
+			return fmt.Errorf("the maximum lag should be positive. Found %d", MAXLAG)
+		}
+		if NBOOT < 1 {
+			return fmt.Errorf("the number of bootstrap resamples should be positive. Found %d", NBOOT)
+		}
+		if CACHESIZE < 0 {
+			return fmt.Errorf("the cache size should be positive. Found %d", CACHESIZE)
+		}
+		switch *crit {
+		case stats.CritAIC.String():
+			CRIT = stats.CritAIC
+		case stats.CritAdjR2.String():
+			CRIT = stats.CritAdjR2
+		case stats.CritCV.String():
+			CRIT = stats.CritCV
+		default:
+			return errors.New("unknown criterion " + *crit)
+		}
+		if *slo != "" {
+			var err error
+			if SLO, err = sliceutil.StrToF64(strings.Split(*slo, ",")); err != nil {
+				return fmt.Errorf("bad SLO thresholds %s %v", *slo, err)
+			}
+		}
+		return nil
+	}
+}
+
+// Add the options of the generated images, return the function checking their values
+func outputFlags(fs *flag.FlagSet) func() error {
+	fs.StringVar(&OUTDIR, "outdir", OUTDIR, "Folder of the generated images (default the current folder)")
+	fs.StringVar(&FORMAT, "format", FORMAT, "Format of the generated images: png or svg")
+	return func() error {
+		if FORMAT != "png" && FORMAT != "svg" {
+			return errors.New("unknown image format " + FORMAT)
+		}
+		if OUTDIR != "" {
+			return os.MkdirAll(OUTDIR, 0755)
+		}
+		return nil
+	}
+}
+
+// Add the options of the results database
+func dbFlags(fs *flag.FlagSet) {
+	fs.StringVar(&DB, "db", DB, "Store the stats of the processed config (mean, percentiles, throughput per abscissa) in the given SQLite database")
+	fs.StringVar(&RUN, "run", RUN, "Identifier of the run stored in the database (default the current time)")
+	fs.StringVar(&GIT, "git", GIT, "Git version tag of the run stored in the database")
+	fs.StringVar(&KAFKA, "kafka", KAFKA, "Kafka version tag of the run stored in the database")
+}
+
+// Return the config with the given name
+func findConfig(name string) (Config, error) {
+	idx := findConfigIdx(name)
+	if idx == -1 {
+		return Config{}, errors.New("No config found with name : " + name)
+	}
+	return Configs[idx], nil
+}
+
+// Return the configs with the given name, or all configs if name is "all"
+func selectConfigs(name string) ([]Config, error) {
+	if name == "all" {
+		return Configs, nil
+	}
+	idx := findConfigIdx(name)
+	if idx == -1 {
+		return nil, errors.New("No config found with name : " + name)
+	}
+	return Configs[idx : idx+1], nil
+}
+
+// plots draw : draw the diagrams of a config or of all configs
+func cmdDraw(ctx context.Context, args []string) error {
+	fs := newFlags("draw", "")
+	checkAnalysis := analysisFlags(fs)
+	checkOutput := outputFlags(fs)
+	dbFlags(fs)
+	c := fs.String("config", "msgSizeAck1", "Name of the config to draw, or all")
+	list := fs.String("draw", Dall.Name(), "Comma separated diagram types:"+helpDraw())
+	n := fs.Int("file", -1, "File number to process as example or -1 for all")
+	if err := parseFlags(fs, args, checkAnalysis, checkOutput); err != nil {
+		return err
+	}
+	ds, err := parseDraws(*list)
+	if err != nil {
+		return err
+	}
+	if *n < -1 {
+		return fmt.Errorf("the file number should be positive. Found %d", *n)
+	}
+	// each file is read by several diagrams
+	CACHE = true
+	if *c == "all" {
+		if DB != "" {
+			return errors.New("the runs are stored in the database for a single config")
+		}
+		return processAllConfigs(ctx, ds, *n)
+	}
+	cfg, err := findConfig(*c)
+	if err != nil {
+		return err
+	}
+	if *n >= len(cfg.sufix) {
+		return fmt.Errorf("the file number should be lower than %d. Found %d", len(cfg.sufix), *n)
+	}
+	if err = drawConfigs(ctx, cfg, ds, *n); err != nil {
+		return err
+	}
+	if DB != "" {
+		if err = cfg.prepare(); err != nil {
+			return err
+		}
+		b, err := computeBaseline(ctx, cfg)
+		if err != nil {
+			return err
+		}
+		return recordRun(b)
+	}
+	return nil
+}
+
+// plots compare : compare the configs of a comparison group (or of all groups) one each other
+func cmdCompare(ctx context.Context, args []string) error {
+	fs := newFlags("compare", "")
+	checkAnalysis := analysisFlags(fs)
+	checkOutput := outputFlags(fs)
+	group := fs.String("group", "all", "Name of the comparison group, or all")
+	names := fs.String("configs", "", "Comma separated names of configs to compare instead of a comparison group")
+	n := fs.Int("abscissa", -1, "Number of the abscissa of the per abscissa comparisons or -1 for all")
+	if err := parseFlags(fs, args, checkAnalysis, checkOutput); err != nil {
+		return err
+	}
+	if *n < -1 {
+		return fmt.Errorf("the abscissa number should be positive. Found %d", *n)
+	}
+	// each file of the compared configs is read by every comparison diagram
+	CACHE = true
+	switch {
+	case *names != "":
+		ComparePNGsuffix = "per_partition"
+		plotfunc.N = 10
+		return compareConfigs(ctx, *names, strings.Split(*names, ","), *n)
+	case *group == "all":
+		return compareAll(ctx, *n)
+	default:
+		idx := findGroupIdx(*group)
+		if idx == -1 {
+			return errors.New("No comparison group found with name : " + *group)
+		}
+		ComparePNGsuffix = "per_partition"
+		plotfunc.N = 10
+		return compareConfigs(ctx, *group, CompareGroups[idx].configs, *n)
+	}
+}
+
+// plots stats : print the stats of each file of a config, save them as a baseline or store them in a database
+func cmdStats(ctx context.Context, args []string) error {
+	fs := newFlags("stats", "")
+	checkAnalysis := analysisFlags(fs)
+	dbFlags(fs)
+	c := fs.String("config", "msgSizeAck1", "Name of the config")
+	save := fs.String("save", "", "Save the stats of the config (mean, p99 and throughput per abscissa) in the given JSON baseline")
+	if err := parseFlags(fs, args, checkAnalysis); err != nil {
+		return err
+	}
+	cfg, err := findConfig(*c)
+	if err != nil {
+		return err
+	}
+	if err = cfg.prepare(); err != nil {
+		return err
+	}
+	b, err := computeBaseline(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	printStats(cfg, b)
+	if *save != "" {
+		if err = saveBaseline(b, *save); err != nil {
+			return err
+		}
+	}
+	if DB != "" {
+		return recordRun(b)
+	}
+	return nil
+}
+
+// Print the stats of each file of the config
+func printStats(c Config, b Baseline) {
+	fmt.Printf("%-10s %8s %11s %11s %11s %11s %11s %11s %11s %11s\n", c.xlabel, "n", "mean(ms)", "sdev(ms)",
+		"median(ms)", "p95(ms)", "p99(ms)", "p999(ms)", "trput(Mb/s)", "msg/s")
+	for _, s := range b.Files {
+		fmt.Printf("%-10s %8d %11.4e %11.4e %11.4e %11.4e %11.4e %11.4e %11.4e %11.4e\n", s.Abscissa, s.N, s.Mean, s.Sdev,
+			s.Median, s.P95, s.P99, s.P999, s.Throughput, s.MsgPerSec)
+	}
+}
+
+// plots check : check the stats of a config against a baseline
+func cmdCheck(ctx context.Context, args []string) error {
+	fs := newFlags("check", "")
+	checkAnalysis := analysisFlags(fs)
+	dbFlags(fs)
+	c := fs.String("config", "msgSizeAck1", "Name of the config")
+	base := fs.String("baseline", "", "JSON baseline saved by plots stats -save")
+	fs.Float64Var(&TOLMEAN, "tolmean", TOLMEAN, "Maximum relative increase of the mean latencies")
+	fs.Float64Var(&TOLP99, "tolp99", TOLP99, "Maximum relative increase of the p99 latencies")
+	fs.Float64Var(&TOLTHR, "tolthr", TOLTHR, "Maximum relative decrease of the throughputs")
+	fs.Float64Var(&ALPHA, "alpha", ALPHA, "Significance level of the Welch test of the increase of the mean latencies")
+	if err := parseFlags(fs, args, checkAnalysis); err != nil {
+		return err
+	}
+	if *base == "" {
+		return errors.New("the check needs a baseline (option -baseline)")
+	}
+	if TOLMEAN < 0 || TOLP99 < 0 || TOLTHR < 0 || ALPHA <= 0 || ALPHA >= 1 {
+		return fmt.Errorf("the tolerances should be positive and the significance level in ]0, 1[. Found %g %g %g %g", TOLMEAN, TOLP99, TOLTHR, ALPHA)
+	}
+	cfg, err := findConfig(*c)
+	if err != nil {
+		return err
+	}
+	if err = cfg.prepare(); err != nil {
+		return err
+	}
+	b, err := computeBaseline(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	if DB != "" {
+		if err = recordRun(b); err != nil {
+			return err
+		}
+	}
+	ok, err := checkBaseline(cfg, b, *base)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("Regression detected against " + *base)
+	}
+	return nil
+}
+
+// plots trend : draw the evolution across the stored runs of the stats of a config at an abscissa
+func cmdTrend(ctx context.Context, args []string) error {
+	fs := newFlags("trend", "")
+	checkOutput := outputFlags(fs)
+	fs.StringVar(&DB, "db", DB, "SQLite database storing the runs")
+	c := fs.String("config", "msgSizeAck1", "Name of the config")
+	abscissa := fs.String("abscissa", "", "Abscissa of the config (e.g. 500)")
+	if err := parseFlags(fs, args, checkOutput); err != nil {
+		return err
+	}
+	if DB == "" || *abscissa == "" {
+		return errors.New("the trend needs a database (option -db) and an abscissa (option -abscissa)")
+	}
+	cfg, err := findConfig(*c)
+	if err != nil {
+		return err
+	}
+	return drawTrend(cfg, *abscissa)
+}
+
+// plots list : list the configs, the comparison groups and the diagram types
+func cmdList(ctx context.Context, args []string) error {
+	fs := newFlags("list", " [configs|groups|draws]...")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	what := fs.Args()
+	if len(what) == 0 {
+		what = []string{"configs", "groups", "draws"}
+	}
+	for _, w := range what {
+		switch w {
+		case "configs":
+			fmt.Println("Configs:")
+			for _, c := range Configs {
+				c.prepare()
+				fmt.Printf("  %-30s %2d files  %-12s %s\n", c.name, len(c.files), c.xlabel, c.root)
+			}
+		case "groups":
+			fmt.Println("Comparison groups:")
+			for _, g := range CompareGroups {
+				fmt.Printf("  %-30s %s\n", g.name, strings.Join(g.configs, ", "))
+			}
+		case "draws":
+			fmt.Println("Diagram types:" + helpDraw())
+		default:
+			return errors.New("unknown list " + w + ", should be configs, groups or draws")
+		}
+	}
+	return nil
+}
+
+// plots serve : serve an HTTP dashboard
+func cmdServe(ctx context.Context, args []string) error {
+	fs := newFlags("serve", "")
+	checkAnalysis := analysisFlags(fs)
+	addr := fs.String("addr", ":8080", "Address listened by the HTTP server")
+	if err := parseFlags(fs, args, checkAnalysis); err != nil {
+		return err
+	}
+	return serve(ctx, *addr)
+}
+
+// plots watch : watch the folders of the configs and redraw the diagrams of the files added or modified
+func cmdWatch(ctx context.Context, args []string) error {
+	fs := newFlags("watch", "")
+	checkAnalysis := analysisFlags(fs)
+	checkOutput := outputFlags(fs)
+	c := fs.String("config", "all", "Name of the watched config, or all")
+	list := fs.String("draw", Dall.Name(), "Comma separated diagram types:"+helpDraw())
+	compare := fs.Bool("compare", false, "Redraw also the comparisons containing the modified configs")
+	fs.IntVar(&POLL, "poll", POLL, "Interval in ms between two scans of the watched folders when inotify is not available")
+	fs.IntVar(&QUIET, "quiet", QUIET, "Time in ms without modification of the watched files before redrawing")
+	if err := parseFlags(fs, args, checkAnalysis, checkOutput); err != nil {
+		return err
+	}
+	if POLL < 1 || QUIET < 0 {
+		return fmt.Errorf("the polling interval and the quiet time should be positive. Found %d %d", POLL, QUIET)
+	}
+	ds, err := parseDraws(*list)
+	if err != nil {
+		return err
+	}
+	confs, err := selectConfigs(*c)
+	if err != nil {
+		return err
+	}
+	return watch(ctx, confs, ds, *compare)
+}
@@ -1,31 +1,157 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"image/color"
 	"math"
+	"math/rand"
 	"path/filepath"
-	"plots/parser"
 	"plots/plotfunc"
 	"plots/sliceutil"
 	"plots/stats"
+	"sort"
+	"strings"
+	"sync"
 
-	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot"
 	"gonum.org/v1/plot/vg"
 )
 
-// If true, print the moments while drawing (option -p)
+// If true, print the moments while drawing (option -print)
 var PRINT = false
 
-// Window interval when using drawSlide (option -l)
+// Window interval when using drawSlide (option -window)
 var NVAL = 5
 
-// Number of columns of the histograms (option -o)
+// Number of columns of the histograms (option -cols)
 var NCOL = 30
 
+// Width in ms of the time buckets when drawing the throughput over time or the heat maps (option -bucket)
+var BUCKET = 1000
+
+// Use a log scale for the latency axis of the (complementary) cumulative distributions (option -logx)
+var LOGX = false
+
+// Use a log scale for the probability axis of the (complementary) cumulative distributions (option -logy)
+var LOGY = false
+
+// Latency thresholds (SLO in ms) drawn on the cumulative distributions together with their exceedance probability (option -slo)
+var SLO []float64
+
+// Nonlinear model fitted on the throughput and the number of messages per second : "", "saturation" or "inverse" (option -fit)
+var NLFIT = ""
+
+// Maximum degree of the polynomial fits of the means with errors, 0 to disable them (option -poly)
+var POLYDEG = 0
+
+// Criterion used to select the degree of the polynomial fits (option -crit)
+var CRIT = stats.CritAIC
+
+// Errors of the means : "stderr" (sdev / sqrt(n)), "ess" (sdev / sqrt(effective sample size)), "batch" (batch means)
+// "boot" or "block" (plain or block bootstrap 95% confidence interval) (option -errs)
+var ERRS = "stderr"
+
+// Number of bootstrap resamples (option -nboot)
+var NBOOT = 1000
+
+// Maximum lag of the autocorrelation functions and of the effective sample sizes (option -maxlag)
+var MAXLAG = 200
+
+// Time step in ms of the uniform grid used by the spectral analysis (option -dt)
+var DT = 10
+
+// Correct the latencies for the clock offset and drift between the producer and consumer hosts (option -skew)
+// the corrected latencies are the delays above the minimum delay
+var SKEW = false
+
+// Latencies in ms above which a warning is printed (option -maxlat)
+var MAXLAT = 60000.
+
+// Maximum number of latencies corrected for the coordinated omission kept per file, beyond the corrected distribution
+// is represented by its quantiles
+const maxCorrected = 1000000
+
+// Intended interval in ms between the sent messages (option -co), used to correct the latencies for
+// the coordinated omission in the percentile and cumulative distribution plots, no correction if 0
+var CO = 0.
+
+// Rule of the number of bins of the histograms (option -bins) : fd (Freedman-Diaconis), scott or sturges
+// NCOL bins if not set, the configs can choose their own rule (field histo)
+var BINS = ""
+
+// Use logarithmically spaced bins for the histograms (option -logbins)
+var LOGBINS = false
+
+// Fit the theoretical distributions (also shifted) on the histograms and draw the best one (option -dist)
+// else the normal distribution of the moments is drawn
+var DIST = false
+
+// Kernel of the density estimations drawn on the histograms (option -kde) : gauss or epanechnikov
+// no estimation drawn if not set (the violins use a gaussian kernel), the configs can choose their own kernel (field histo)
+var KERNEL = ""
+
+// Bandwidth rule of the density estimations (option -bw) : silverman or sj (Sheather-Jones)
+var BW = "silverman"
+
+// Width in ms of the time windows of the sliding percentiles (option -tw), 0 = windows of NVAL messages
+var TW = 0
+
+// Method of detection of the outliers (option -out), no detection if not set
+var OUTLIERS = ""
+
+// Threshold of the outlier detection, in IQRs beyond the quartiles or in scaled MADs from the median (option -outk)
+var OUTK = 3.
+
+// Half width of the sliding window of the Hampel filter (option -hw)
+var HAMPELW = 50
+
+// Exclude the outliers from the moments and the fits (option -exclude)
+var EXCLUDE = false
+
+// Method of detection of the change points of the mean (option -cp), no detection if not set
+var CHANGES = ""
+
+// Penalty per change point of the pelt and binseg methods (option -cppen), 0 = BIC penalty 2 ln(n)
+var CPPEN = 0.
+
+// Minimum number of messages between two change points of a latency series (option -cpmin)
+var CPMIN = 50
+
 // A suffixe to be added to the PNG when comparing configs
 var ComparePNGsuffix string
 
+// Folder of the generated images (option -outdir), the current folder if not set
+var OUTDIR = ""
+
+// Format of the generated images (option -format) : png or svg
+var FORMAT = "png"
+
+// Save the plot in OUTDIR with the FORMAT of the images
+// name is the image name with the png extension
+func savePlot(p *plot.Plot, w, h vg.Length, name string) error {
+	name = strings.TrimSuffix(name, ".png") + "." + FORMAT
+	return p.Save(w, h, filepath.Join(OUTDIR, name))
+}
+
+// Messages already printed by printOnce
+var (
+	printedMu sync.Mutex
+	printed   = make(map[string]bool)
+)
+
+// Print the message if it has not been printed yet, e.g. a warning about a file read by several diagrams
+func printOnce(format string, a ...interface{}) {
+	msg := fmt.Sprintf(format, a...)
+	printedMu.Lock()
+	defer printedMu.Unlock()
+	if !printed[msg] {
+		printed[msg] = true
+		fmt.Print(msg)
+	}
+}
+
 // Print the values of x and y to screen
 func print(x []string, y []float64, label string) {
 	if !PRINT {
@@ -37,87 +163,155 @@ func print(x []string, y []float64, label string) {
 	}
 }
 
-// Process the comparison of the specified configs
-func doCompare(confs []Config) error {
+// Return the tasks comparing the specified configs, "name" is the name of the comparison in the errors
+// "n" is the number of the abscissa for the per abscissa comparisons (-1 = all abscissa)
+func compareTasks(name string, confs []Config, n int) ([]task, error) {
 	cfgs := make([]Config, len(confs))
 	for i, c := range confs {
-		c.prepare()
+		if err := c.prepare(); err != nil {
+			return nil, err
+		}
 		cfgs[i] = c
 	}
-	if err := compareThroughputs(cfgs); err != nil {
-		return err
-	}
-	if err := compareNbMsgPerSec(cfgs); err != nil {
-		return err
-	}
-	if err := compareMeansErr(cfgs); err != nil {
-		return err
-	}
-	if err := compareMeansLine(cfgs); err != nil {
+	return []task{
+		{name, "", Dthroughput.Name(), func(ctx context.Context) error { return compareThroughputs(ctx, cfgs) }},
+		{name, "", DnbMsgPerSec.Name(), func(ctx context.Context) error { return compareNbMsgPerSec(ctx, cfgs) }},
+		{name, "", DmeansErrFiles.Name(), func(ctx context.Context) error { return compareMeansErr(ctx, cfgs) }},
+		{name, "", DmeansFile.Name(), func(ctx context.Context) error { return compareMeansLine(ctx, cfgs) }},
+		{name, "", DboxFiles.Name(), func(ctx context.Context) error { return compareBoxes(ctx, cfgs) }},
+		{name, "", DviolinFiles.Name(), func(ctx context.Context) error { return compareViolins(ctx, cfgs) }},
+		{name, "", DcdfFile.Name(), func(ctx context.Context) error { return compareCcdf(ctx, cfgs, n) }},
+		{name, "", DqqFile.Name(), func(ctx context.Context) error { return compareQQ(ctx, cfgs, n) }},
+	}, nil
+}
+
+// Process the comparison of the specified configs, "name" is the name of the comparison in the errors
+// "n" is the number of the abscissa for the per abscissa comparisons (-1 = all abscissa)
+func doCompare(ctx context.Context, name string, confs []Config, n int) error {
+	tasks, err := compareTasks(name, confs, n)
+	if err != nil {
 		return err
 	}
-	return nil
+	return runTasks(ctx, tasks)
 }
 
 // used to pass the func as first citizen
-type fdraw func(string, int) error
-
-// Draw the function for one or all files, according to the value of "n"
-func drawCFiles(c Config, n int, f fdraw) {
-	if n < 0 {
-		for _, file := range c.files {
-			if err := f(file, c.nbPtsDiscard); err != nil {
-				panic(err)
+type fdraw func(Config, string) error
+
+// Adapt a drawing of a file that only needs the number of points to discard of the config
+func discarding(f func(string, int) error) fdraw {
+	return func(c Config, filename string) error {
+		return f(filename, c.nbPtsDiscard)
+	}
+}
+
+// Diagrams drawn for each data file of a config
+var fileDraws = []struct {
+	d Draws
+	f fdraw
+}{
+	{Dfile, discarding(drawFile)},
+	{DslideFile, discarding(drawSlideFile)},
+	{DslideQuant, discarding(drawSlideQuantFile)},
+	{DhistoFile, drawHistoFile},
+	{DcdfFile, discarding(drawCdfFile)},
+	{DpercentileFile, discarding(drawPercentileFile)},
+	{DqqFile, discarding(drawQQFile)},
+	{DacfFile, discarding(drawAcfFile)},
+	{DspectrumFile, discarding(drawSpectrumFile)},
+	{DheatmapFile, discarding(drawHeatmapFile)},
+}
+
+// Diagrams drawn from all data files of a config
+var configDraws = []struct {
+	d Draws
+	f func(context.Context, Config) error
+}{
+	{DcdfFile, drawCcdfFiles},
+	{DmeansFile, drawMeansFiles},
+	{DmeansErrFiles, drawMeansErrFiles},
+	{Dthroughput, drawThroughputsFiles},
+	{DnbMsgPerSec, drawNbMsgPerSecFiles},
+	{DboxFiles, drawBoxFiles},
+	{DviolinFiles, drawViolinFiles},
+}
+
+// Return the tasks drawing the diagrams of each file of the config "c" according to the Draws enum "d" value
+// "n" is the number of the config sample file (-1 = draw all files of the config)
+func fileTasks(c Config, d Draws, n int) []task {
+	var tasks []task
+	for i := range c.files {
+		if n >= 0 && i != n {
+			continue
+		}
+		file, nb := c.files[i], i
+		for _, fd := range fileDraws {
+			if d != Dall && d != fd.d {
+				continue
 			}
+			f := fd.f
+			tasks = append(tasks, task{c.name, file, fd.d.Name(), func(context.Context) error {
+				return f(c, file)
+			}})
 		}
-	} else {
-		if err := f(c.files[n], c.nbPtsDiscard); err != nil {
-			panic(err)
+		if d == Dall || d == DthroughputTime {
+			tasks = append(tasks, task{c.name, file, DthroughputTime.Name(), func(ctx context.Context) error {
+				return drawThroughputTimeFiles(ctx, c, nb)
+			}})
 		}
 	}
+	return tasks
 }
 
-// Draw a single config "c" according to the Draws enum "d" value
-// "n" is the number of the config sample file (-1 = draw all files of the config)
-func drawConfig(c Config, d Draws, n int) {
-	c.prepare()
-
-	if d == Dall || d == Dfile {
-		drawCFiles(c, n, drawFile)
-	}
-	if d == Dall || d == DslideFile {
-		drawCFiles(c, n, drawSlideFile)
-	}
-	if d == Dall || d == DhistoFile {
-		drawCFiles(c, n, drawHistoFile)
-	}
-	if d == Dall || d == DmeansFile {
-		if err := drawMeansFiles(c); err != nil {
-			panic(err)
+// Return the tasks drawing the diagrams summarizing all files of the config "c" according to the Draws enum "d" value
+func summaryTasks(c Config, d Draws) []task {
+	var tasks []task
+	for _, cd := range configDraws {
+		if d != Dall && d != cd.d {
+			continue
 		}
+		f := cd.f
+		tasks = append(tasks, task{c.name, "", cd.d.Name(), func(ctx context.Context) error { return f(ctx, c) }})
 	}
-	if d == Dall || d == DmeansErrFiles {
-		if err := drawMeansErrFiles(c); err != nil {
-			panic(err)
-		}
+	return tasks
+}
+
+// Return the tasks drawing a single config "c" according to the Draws enum "d" value
+// "n" is the number of the config sample file (-1 = draw all files of the config)
+func configTasks(c Config, d Draws, n int) ([]task, error) {
+	if err := c.prepare(); err != nil {
+		return nil, err
 	}
-	if d == Dall || d == Dthroughput {
-		if err := drawThroughputsFiles(c); err != nil {
-			panic(err)
-		}
+	return append(fileTasks(c, d, n), summaryTasks(c, d)...), nil
+}
+
+// Draw a single config "c" according to the Draws enum "d" value
+// "n" is the number of the config sample file (-1 = draw all files of the config)
+// without KEEPGOING, stop at the first failure
+func drawConfig(ctx context.Context, c Config, d Draws, n int) error {
+	tasks, err := configTasks(c, d, n)
+	if err != nil {
+		return err
 	}
-	if d == Dall || d == DnbMsgPerSec {
-		if err := drawNbMsgPerSecFiles(c); err != nil {
-			panic(err)
-		}
+	return runTasks(ctx, tasks)
+}
+
+// Return the size of the messages (in kb) of each file of the config
+func msgSizes(c Config) ([]float64, error) {
+	if c.abscisIsSz {
+		return sliceutil.StrToF64(c.abscis)
 	}
+	return sliceutil.FillF64(c.kb, len(c.files)), nil
 }
 
 // Compute the number of messages per seconds for each file
-func computeNbMsgPerSecFiles(files []string, nbPtsDiscard int) ([]float64, error) {
+func computeNbMsgPerSecFiles(ctx context.Context, files []string, nbPtsDiscard int) ([]float64, error) {
 	trput := make([]float64, len(files))
 	for i, f := range files {
-		ts1, ts2, err := parser.ParseData(f)
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		ts1, ts2, err := parseData(f)
 		if err != nil {
 			return nil, err
 		}
@@ -129,14 +323,14 @@ func computeNbMsgPerSecFiles(files []string, nbPtsDiscard int) ([]float64, error
 }
 
 // Comparison of number of messages per seconds for different configs
-func compareNbMsgPerSec(confs []Config) error {
+func compareNbMsgPerSec(ctx context.Context, confs []Config) error {
 	// Create the plot
 	p, err := plotfunc.NewPlot("Msg / s", confs[0].xlabel, "nb of Msg / s")
 	if err != nil {
 		return err
 	}
 	for i, c := range confs {
-		trput, err := computeNbMsgPerSecFiles(c.files, c.nbPtsDiscard)
+		trput, err := computeNbMsgPerSecFiles(ctx, c.files, c.nbPtsDiscard)
 		if err != nil {
 			return err
 		}
@@ -150,14 +344,14 @@ func compareNbMsgPerSec(confs []Config) error {
 		}
 	}
 	// Save the plot to a PNG file.
-	return p.Save(10*vg.Centimeter, 10*vg.Centimeter, confs[0].xlabel+"_nbMsgPerSec_"+ComparePNGsuffix+".png")
+	return savePlot(p, 10*vg.Centimeter, 10*vg.Centimeter, confs[0].xlabel+"_nbMsgPerSec_"+ComparePNGsuffix+".png")
 }
 
 // Compute the number of messages per second for every dataset and draw it
 // files : files to parse
 // sizes : files corresponding abcissa
-func drawNbMsgPerSecFiles(c Config) error {
-	trput, err := computeNbMsgPerSecFiles(c.files, c.nbPtsDiscard)
+func drawNbMsgPerSecFiles(ctx context.Context, c Config) error {
+	trput, err := computeNbMsgPerSecFiles(ctx, c.files, c.nbPtsDiscard)
 	if err != nil {
 		return err
 	}
@@ -174,10 +368,13 @@ func drawNbMsgPerSecFiles(c Config) error {
 }
 
 // Compute the throughput for each file
-func computeThoughputFiles(files []string, sizes []float64, nbPtsDiscard int) ([]float64, error) {
+func computeThoughputFiles(ctx context.Context, files []string, sizes []float64, nbPtsDiscard int) ([]float64, error) {
 	trput := make([]float64, len(files))
 	for i, f := range files {
-		ts1, ts2, err := parser.ParseData(f)
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		ts1, ts2, err := parseData(f)
 		if err != nil {
 			return nil, err
 		}
@@ -191,68 +388,711 @@ func computeThoughputFiles(files []string, sizes []float64, nbPtsDiscard int) ([
 }
 
 // Comparison of throughputs for different configs
-func compareThroughputs(confs []Config) error {
+func compareThroughputs(ctx context.Context, confs []Config) error {
 	// Create the plot
 	p, err := plotfunc.NewPlot("Throughputs", confs[0].xlabel, "nb of Mb / s")
 	if err != nil {
 		return err
 	}
-	var sizes []float64
-	for i, c := range confs {
-		if c.abscisIsSz {
-			sizes, err = sliceutil.StrToF64(c.abscis)
-			if err != nil {
-				return err
-			}
-		} else {
-			sizes = sliceutil.FillF64(c.kb, len(c.files))
-		}
-		trput, err := computeThoughputFiles(c.files, sizes, c.nbPtsDiscard)
-		if err != nil {
-			return err
-		}
-		print(c.abscis, trput, "Throughput")
-		x, err := sliceutil.StrToF64(c.abscis)
-		if err != nil {
-			return err
-		}
-		if err = plotfunc.AddWithLineXY(x, trput, c.legend(), i, p); err != nil {
+	for i, c := range confs {
+		sizes, err := msgSizes(c)
+		if err != nil {
+			return err
+		}
+		trput, err := computeThoughputFiles(ctx, c.files, sizes, c.nbPtsDiscard)
+		if err != nil {
+			return err
+		}
+		print(c.abscis, trput, "Throughput")
+		x, err := sliceutil.StrToF64(c.abscis)
+		if err != nil {
+			return err
+		}
+		if err = plotfunc.AddWithLineXY(x, trput, c.legend(), i, p); err != nil {
+			return err
+		}
+	}
+	// Save the plot to a PNG file.
+	return savePlot(p, 10*vg.Centimeter, 10*vg.Centimeter, confs[0].xlabel+"_throughputs_"+ComparePNGsuffix+".png")
+}
+
+// Compute the throughput for every dataset and draw it
+// files : files to parse
+// sizes : files corresponding abcissa
+func drawThroughputsFiles(ctx context.Context, c Config) error {
+	sizes, err := msgSizes(c)
+	if err != nil {
+		return err
+	}
+	trput, err := computeThoughputFiles(ctx, c.files, sizes, c.nbPtsDiscard)
+	if err != nil {
+		return err
+	}
+	print(c.abscis, trput, "Throughput")
+	base := filepath.Base(c.root)
+	if isNumDot(c.abscis[0]) {
+		x, err := sliceutil.StrToF64(c.abscis)
+		if err != nil {
+			return err
+		}
+		return drawPointsXY(x, trput, c.xlabel, "nb of Mb / s", base+c.title, base+"_throughputs.png")
+	} else {
+		return drawBar(c.abscis, trput, []string{c.xlabel}, "nb of Mb / s", "Throughput", base+"_throughputs.png")
+	}
+}
+
+// Compute the number of messages received in each time bucket of "bucket" ms
+// Returns the start of the buckets (in s), the nb of msg / s and the nb of Mb / s
+// size : size of the messages in kb
+func computeThroughputTime(filename string, size float64, nbPtsDiscard int, bucket int) ([]float64, []float64, []float64, error) {
+	_, ts2, err := parseData(filename)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	starts, counts, err := stats.BucketCounts(ts2[nbPtsDiscard:], int64(bucket)*1000000)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	// the last bucket is usually incomplete
+	if len(counts) > 1 {
+		starts, counts = starts[:len(starts)-1], counts[:len(counts)-1]
+	}
+	seconds := float64(bucket) / 1000.
+	x := sliceutil.MapF64(starts, func(t float64) float64 { return t / 1.e9 })
+	nbMsg := sliceutil.MapF64(counts, func(n float64) float64 { return n / seconds })
+	mb := sliceutil.MapF64(nbMsg, func(n float64) float64 { return n * size / 1000. })
+	return x, nbMsg, mb, nil
+}
+
+// Compute and draw the throughput over time for one or all files, according to the value of "n"
+// image names = ${filename}_nbmsgpersec_time.png and ${filename}_throughput_time.png
+func drawThroughputTimeFiles(ctx context.Context, c Config, n int) error {
+	sizes, err := msgSizes(c)
+	if err != nil {
+		return err
+	}
+	for i, f := range c.files {
+		if n >= 0 && i != n {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		x, nbMsg, mb, err := computeThroughputTime(f, sizes[i], c.nbPtsDiscard, BUCKET)
+		if err != nil {
+			return err
+		}
+		base := filepath.Base(f)
+		if err = drawTimeSeries(x, nbMsg, "nb of msg / s", base, base+"_nbmsgpersec_time.png"); err != nil {
+			return err
+		}
+		if err = drawTimeSeries(x, mb, "nb of Mb / s", base, base+"_throughput_time.png"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Parse a file and draw the density of the latencies over time as a heat map
+// x = time since the first sent message in buckets of BUCKET ms, y = NCOL log spaced latency buckets
+// image name = ${filename}_heatmap.png
+func drawHeatmapFile(filename string, nbPtsDiscard int) error {
+	t, lat, err := parseLatencies(filename, nbPtsDiscard)
+	if err != nil {
+		return err
+	}
+	// the log scale only accepts positive latencies
+	pos := sliceutil.FilterF64(lat, func(v float64) bool { return v > 0 })
+	if len(pos) == 0 {
+		return errors.New("No positive latency in " + filename)
+	}
+	lmin, lmax := sliceutil.MinMax(pos)
+	if lmax == lmin {
+		lmax = lmin * 1.01
+	}
+	yedges, err := stats.LogBins(lmin, lmax, NCOL)
+	if err != nil {
+		return err
+	}
+	tmin, tmax := sliceutil.MinMax(t)
+	bucket := float64(BUCKET) / 1000.
+	nx := int((tmax-tmin)/bucket) + 1
+	xedges := make([]float64, nx+1)
+	for i := range xedges {
+		xedges[i] = tmin + float64(i)*bucket
+	}
+	counts := stats.Histo2D(t, lat, xedges, yedges)
+	// centers of the cells
+	x := make([]float64, nx)
+	for i := range x {
+		x[i] = (xedges[i] + xedges[i+1]) / 2.
+	}
+	y := make([]float64, NCOL)
+	for j := range y {
+		y[j] = math.Sqrt(yedges[j] * yedges[j+1])
+	}
+	base := filepath.Base(filename)
+	// Create the plot
+	p, err := plotfunc.NewPlot(base+"\n(colour = log10 of the nb of msg)", "time (s)", "times (ms)")
+	if err != nil {
+		return err
+	}
+	if err = plotfunc.AddHeatMap(x, y, counts, p); err != nil {
+		return err
+	}
+	// Save the plot to a PNG file.
+	return savePlot(p, 15*vg.Centimeter, 10*vg.Centimeter, base+"_heatmap.png")
+}
+
+// Parse all files of the config
+// return the latencies (in ms) of each file without the first nbPtsDiscard points
+func parseFiles(ctx context.Context, c Config) ([][]float64, error) {
+	values := make([][]float64, len(c.files))
+	for i, f := range c.files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		fvalues, err := parseFile(f)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = fvalues[c.nbPtsDiscard:]
+	}
+	return values, nil
+}
+
+// Draw the latency distribution of each file of the config side by side as box plots
+// image name = ${root}_box.png
+func drawBoxFiles(ctx context.Context, c Config) error {
+	values, err := parseFiles(ctx, c)
+	if err != nil {
+		return err
+	}
+	base := filepath.Base(c.root)
+	// Create the plot
+	p, err := plotfunc.NewPlot(base+c.title, c.xlabel, "times (ms)")
+	if err != nil {
+		return err
+	}
+	for i, v := range values {
+		if err = plotfunc.AddBoxPlot(v, float64(i), 0, "", 0, p); err != nil {
+			return err
+		}
+	}
+	p.NominalX(c.abscis...)
+	// Save the plot to a PNG file.
+	return savePlot(p, vg.Length(len(values)+5)*vg.Centimeter, 10*vg.Centimeter, base+"_box.png")
+}
+
+// Kernel density estimation of the data at x with the kernel and the bandwidth rule of the settings h
+// (a gaussian kernel if none). Returns the density and the bandwidth
+func kernelDensity(data, x []float64, h Histo) ([]float64, float64, error) {
+	bw, err := h.bandwidth(data)
+	if err != nil {
+		return nil, 0, err
+	}
+	k, err := h.densityKernel()
+	if err != nil {
+		return nil, 0, err
+	}
+	return stats.KernelDensity(data, bw, x, k), bw, nil
+}
+
+// Evaluate the density of the data on a regular grid of n points between its min and max
+// with the kernel and the bandwidth rule of the settings h, return the grid, the density and the median of the data
+// or an error if the settings are unknown
+func violinDensity(data []float64, n int, h Histo) ([]float64, []float64, float64, error) {
+	min, max := sliceutil.MinMax(data)
+	y := make([]float64, n)
+	for i := range y {
+		y[i] = min + (max-min)*float64(i)/float64(n-1)
+	}
+	sorted := make([]float64, len(data))
+	copy(sorted, data)
+	sort.Float64s(sorted)
+	median := sorted[len(sorted)/2]
+	dens, _, err := kernelDensity(data, y, h)
+	return y, dens, median, err
+}
+
+// Draw the latency distribution of each file of the config side by side as violin plots
+// image name = ${root}_violin.png
+func drawViolinFiles(ctx context.Context, c Config) error {
+	values, err := parseFiles(ctx, c)
+	if err != nil {
+		return err
+	}
+	base := filepath.Base(c.root)
+	// Create the plot
+	p, err := plotfunc.NewPlot(base+c.title, c.xlabel, "times (ms)")
+	if err != nil {
+		return err
+	}
+	for i, v := range values {
+		y, dens, median, err := violinDensity(v, 100, c.histo)
+		if err != nil {
+			return err
+		}
+		if err = plotfunc.AddViolin(y, dens, float64(i), 0.8, median, "", 0, p); err != nil {
+			return err
+		}
+	}
+	p.NominalX(c.abscis...)
+	// Save the plot to a PNG file.
+	return savePlot(p, vg.Length(len(values)+5)*vg.Centimeter, 10*vg.Centimeter, base+"_violin.png")
+}
+
+// Comparison of the latency distributions for different configs as box plots grouped by abscissa
+func compareBoxes(ctx context.Context, confs []Config) error {
+	// Create the plot
+	p, err := plotfunc.NewPlot("Latency distributions", confs[0].xlabel, "times (ms)")
+	if err != nil {
+		return err
+	}
+	m := float64(len(confs))
+	for k, c := range confs {
+		values, err := parseFiles(ctx, c)
+		if err != nil {
+			return err
+		}
+		offset := vg.Points(15 * (float64(k) - (m-1)/2.))
+		for i, v := range values {
+			legend := ""
+			if i == 0 {
+				legend = c.legend()
+			}
+			if err = plotfunc.AddBoxPlot(v, float64(i), offset, legend, k, p); err != nil {
+				return err
+			}
+		}
+	}
+	p.NominalX(confs[0].abscis...)
+	// Save the plot to a PNG file.
+	return savePlot(p, vg.Length(len(confs[0].files)*len(confs)+5)*vg.Centimeter/2, 10*vg.Centimeter, confs[0].xlabel+"_box_"+ComparePNGsuffix+".png")
+}
+
+// Comparison of the latency distributions for different configs as violin plots grouped by abscissa
+func compareViolins(ctx context.Context, confs []Config) error {
+	// Create the plot
+	p, err := plotfunc.NewPlot("Latency distributions", confs[0].xlabel, "times (ms)")
+	if err != nil {
+		return err
+	}
+	m := float64(len(confs))
+	width := 0.8 / m
+	for k, c := range confs {
+		values, err := parseFiles(ctx, c)
+		if err != nil {
+			return err
+		}
+		for i, v := range values {
+			legend := ""
+			if i == 0 {
+				legend = c.legend()
+			}
+			y, dens, median, err := violinDensity(v, 100, c.histo)
+			if err != nil {
+				return err
+			}
+			loc := float64(i) + (float64(k)-(m-1)/2.)*width
+			if err = plotfunc.AddViolin(y, dens, loc, width, median, legend, k, p); err != nil {
+				return err
+			}
+		}
+	}
+	p.NominalX(confs[0].abscis...)
+	// Save the plot to a PNG file.
+	return savePlot(p, vg.Length(len(confs[0].files)*len(confs)+5)*vg.Centimeter/2, 10*vg.Centimeter, confs[0].xlabel+"_violin_"+ComparePNGsuffix+".png")
+}
+
+// Compute the empirical (complementary if ccdf) cumulative distribution of the data
+// remove the points that cannot be drawn on log axes
+func cdfPoints(data []float64, ccdf bool) ([]float64, []float64) {
+	var x, p []float64
+	if ccdf {
+		x, p = stats.CCDF(data)
+	} else {
+		x, p = stats.ECDF(data)
+	}
+	xs := make([]float64, 0, len(x))
+	ps := make([]float64, 0, len(p))
+	for i := range x {
+		if (LOGX && x[i] <= 0) || (LOGY && p[i] <= 0) {
+			continue
+		}
+		xs = append(xs, x[i])
+		ps = append(ps, p[i])
+	}
+	return xs, ps
+}
+
+// Draw the empirical (complementary if ccdf) cumulative distributions of the datasets in the same plot
+// add the SLO thresholds and print their exceedance probabilities if PRINT is set
+func drawCdf(data [][]float64, legends []string, ccdf bool, title, outPng string) error {
+	ylabel := "p(latency <= x)"
+	if ccdf {
+		ylabel = "p(latency > x)"
+	}
+	// Create the plot
+	p, err := plotfunc.NewPlot(title, "Latency (ms)", ylabel)
+	if err != nil {
+		return err
+	}
+	pmin := 1.
+	for i, d := range data {
+		x, y := cdfPoints(d, ccdf)
+		if len(x) == 0 {
+			continue
+		}
+		if y[0] < pmin {
+			pmin = y[0]
+		}
+		if y[len(y)-1] < pmin {
+			pmin = y[len(y)-1]
+		}
+		if err = plotfunc.AddWithLineXY(x, y, legends[i], i, p); err != nil {
+			return err
+		}
+	}
+	// Add the SLO thresholds
+	for _, slo := range SLO {
+		if LOGX && slo <= 0 {
+			continue
+		}
+		if PRINT {
+			for i, d := range data {
+				label := legends[i]
+				if label == "" {
+					label = title
+				}
+				fmt.Printf("p(latency > %gms) = %.3e %s\n", slo, stats.Exceedance(d, slo), label)
+			}
+		}
+		if err = plotfunc.AddVLine(slo, pmin, 1, "", color.Black, p); err != nil {
+			return err
+		}
+	}
+	if LOGX {
+		plotfunc.SetLogX(p)
+	}
+	if LOGY {
+		plotfunc.SetLogY(p)
+	}
+	// Save the plot to a PNG file.
+	return savePlot(p, 10*vg.Centimeter, 10*vg.Centimeter, outPng)
+}
+
+// call parseFile and drawCdf for the cumulative and the complementary cumulative distributions
+// image names = ${filename}_ecdf.png and ${filename}_ccdf.png
+func drawCdfFile(filename string, nbPtsDiscard int) error {
+	fvalues, err := parseFile(filename)
+	if err != nil {
+		return err
+	}
+	base := filepath.Base(filename)
+	data := [][]float64{fvalues[nbPtsDiscard:]}
+	legends := []string{""}
+	// overlay the distribution corrected for the coordinated omission
+	if CO > 0 {
+		corrected, err := stats.CorrectOmission(data[0], CO, maxCorrected)
+		if err != nil {
+			return err
+		}
+		data = append(data, corrected)
+		legends = []string{"raw", "corrected"}
+	}
+	if err = drawCdf(data, legends, false, base, base+"_ecdf.png"); err != nil {
+		return err
+	}
+	return drawCdf(data, legends, true, base, base+"_ccdf.png")
+}
+
+// Parse a file and draw its percentile distribution (latency vs percentile, HdrHistogram style),
+// with the distribution corrected for the coordinated omission if CO is set, the main percentiles are printed if PRINT is set
+// image name = ${filename}_percentiles.png
+func drawPercentileFile(filename string, nbPtsDiscard int) error {
+	fvalues, err := parseFile(filename)
+	if err != nil {
+		return err
+	}
+	base := filepath.Base(filename)
+	data := [][]float64{fvalues[nbPtsDiscard:]}
+	legends := []string{"raw"}
+	title := base
+	if CO > 0 {
+		corrected, err := stats.CorrectOmission(data[0], CO, maxCorrected)
+		if err != nil {
+			return err
+		}
+		data = append(data, corrected)
+		legends = append(legends, "corrected")
+		title = fmt.Sprintf("%s\n(interval=%gms)", base, CO)
+	}
+	// Create the plot
+	p, err := plotfunc.NewPlot(title, "Percentile", "Latency (ms)")
+	if err != nil {
+		return err
+	}
+	p.Legend.Left = true
+	for i, d := range data {
+		x, q := stats.PercentileSpectrum(d)
+		if err = plotfunc.AddWithLineXY(x, q, legends[i], i, p); err != nil {
+			return err
+		}
+		if PRINT {
+			fmt.Printf("Percentiles (%s) : n=%d", legends[i], len(d))
+			for _, pc := range []float64{0.5, 0.9, 0.99, 0.999, 0.9999} {
+				fmt.Printf(" p%g=%.3e", 100*pc, stats.Quantile(d, pc))
+			}
+			fmt.Printf(" %s\n", base)
+		}
+	}
+	plotfunc.SetPercentileX(p)
+	// Save the plot to a PNG file.
+	return savePlot(p, 15*vg.Centimeter, 10*vg.Centimeter, base+"_percentiles.png")
+}
+
+// Draw the complementary cumulative distributions of all files of the config in the same plot
+// image name = ${root}_ccdf.png
+func drawCcdfFiles(ctx context.Context, c Config) error {
+	values, err := parseFiles(ctx, c)
+	if err != nil {
+		return err
+	}
+	base := filepath.Base(c.root)
+	return drawCdf(values, c.abscis, true, base+c.title, base+"_ccdf.png")
+}
+
+// Comparison of the complementary cumulative distributions of different configs
+// one plot for the abscissa number "n" or for each abscissa if n < 0
+func compareCcdf(ctx context.Context, confs []Config, n int) error {
+	values := make([][][]float64, len(confs))
+	legends := make([]string, len(confs))
+	for k, c := range confs {
+		v, err := parseFiles(ctx, c)
+		if err != nil {
+			return err
+		}
+		values[k] = v
+		legends[k] = c.legend()
+	}
+	for i, abscis := range confs[0].abscis {
+		if n >= 0 && i != n {
+			continue
+		}
+		data := make([][]float64, 0, len(confs))
+		for k := range confs {
+			if i < len(values[k]) {
+				data = append(data, values[k][i])
+			}
+		}
+		title := fmt.Sprintf("%s = %s", confs[0].xlabel, abscis)
+		outPng := confs[0].xlabel + "_" + abscis + "_ccdf_" + ComparePNGsuffix + ".png"
+		if err := drawCdf(data, legends[:len(data)], true, title, outPng); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Parse a file and draw its quantile-quantile plots against the fitted theoretical distributions
+// image name = ${filename}_qq.png
+func drawQQFile(filename string, nbPtsDiscard int) error {
+	fvalues, err := parseFile(filename)
+	if err != nil {
+		return err
+	}
+	data := fvalues[nbPtsDiscard:]
+	base := filepath.Base(filename)
+	// Create the plot
+	p, err := plotfunc.NewPlot(base, "theoretical quantiles (ms)", "sample quantiles (ms)")
+	if err != nil {
+		return err
+	}
+	min, max := sliceutil.MinMax(data)
+	for i, d := range stats.FitDistributions(data) {
+		theo, sample := stats.QQ(data, d, 500)
+		if err = plotfunc.AddWithPointsXY(theo, sample, d.Name(), i, p); err != nil {
+			return err
+		}
+	}
+	// Add the first bisector
+	if err = plotfunc.AddStraightLine(min, min, max, max, "", color.Black, p); err != nil {
+		return err
+	}
+	// Save the plot to a PNG file.
+	return savePlot(p, 10*vg.Centimeter, 10*vg.Centimeter, base+"_qq.png")
+}
+
+// Comparison of the latency quantiles of different configs against the first one (two-sample Q-Q plots)
+// one plot for the abscissa number "n" or for each abscissa if n < 0
+func compareQQ(ctx context.Context, confs []Config, n int) error {
+	if len(confs) < 2 {
+		return nil
+	}
+	values := make([][][]float64, len(confs))
+	for k, c := range confs {
+		v, err := parseFiles(ctx, c)
+		if err != nil {
+			return err
+		}
+		values[k] = v
+	}
+	for i, abscis := range confs[0].abscis {
+		if n >= 0 && i != n {
+			continue
+		}
+		title := fmt.Sprintf("%s = %s", confs[0].xlabel, abscis)
+		// Create the plot
+		p, err := plotfunc.NewPlot(title, confs[0].legend()+" quantiles (ms)", "quantiles (ms)")
+		if err != nil {
+			return err
+		}
+		min, max := sliceutil.MinMax(values[0][i])
+		for k := 1; k < len(confs); k++ {
+			if i >= len(values[k]) {
+				continue
+			}
+			qa, qb := stats.QQ2(values[0][i], values[k][i], 500)
+			if err = plotfunc.AddWithPointsXY(qa, qb, confs[k].legend(), k, p); err != nil {
+				return err
+			}
+		}
+		// Add the first bisector, points below are better than the first config
+		if err = plotfunc.AddStraightLine(min, min, max, max, "", color.Black, p); err != nil {
+			return err
+		}
+		// Save the plot to a PNG file.
+		outPng := confs[0].xlabel + "_" + abscis + "_qq_" + ComparePNGsuffix + ".png"
+		if err = savePlot(p, 10*vg.Centimeter, 10*vg.Centimeter, outPng); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Parse a file and draw the autocorrelation function of the latencies up to lag MAXLAG
+// with the 95% confidence limits of an uncorrelated series, the effective sample size is added to the title
+// image name = ${filename}_acf.png
+func drawAcfFile(filename string, nbPtsDiscard int) error {
+	fvalues, err := parseFile(filename)
+	if err != nil {
+		return err
+	}
+	data := fvalues[nbPtsDiscard:]
+	maxlag := MAXLAG
+	if maxlag >= len(data) {
+		maxlag = len(data) - 1
+	}
+	rho, err := stats.Autocorr(data, maxlag)
+	if err != nil {
+		return err
+	}
+	ess, err := stats.EffectiveSampleSize(data, MAXLAG)
+	if err != nil {
+		return err
+	}
+	if PRINT {
+		fmt.Printf("Effective sample size : n=%d ess=%.3e %s\n", len(data), ess, filepath.Base(filename))
+	}
+	base := filepath.Base(filename)
+	// Create the plot
+	p, err := plotfunc.NewPlot(fmt.Sprintf("%s\n(n=%d ess=%.0f)", base, len(data), ess), "lag (msg)", "autocorrelation")
+	if err != nil {
+		return err
+	}
+	if err = plotfunc.AddWithLine(rho, "", 0, p); err != nil {
+		return err
+	}
+	lim := 1.96 / math.Sqrt(float64(len(data)))
+	for _, y := range []float64{0, lim, -lim} {
+		if err = plotfunc.AddHLine(y, 0, float64(maxlag), "", color.Black, p); err != nil {
+			return err
+		}
+	}
+	// Save the plot to a PNG file.
+	return savePlot(p, 10*vg.Centimeter, 10*vg.Centimeter, base+"_acf.png")
+}
+
+// Parse a file, resample the latencies onto a uniform time grid of DT ms (time of the sent messages)
+// and draw their periodogram, the 3 dominant periods are drawn as vertical lines and printed if PRINT is set
+// image name = ${filename}_spectrum.png
+func drawSpectrumFile(filename string, nbPtsDiscard int) error {
+	t, lat, err := parseLatencies(filename, nbPtsDiscard)
+	if err != nil {
+		return err
+	}
+	dt := float64(DT) / 1000.
+	_, values, err := stats.Resample(t, lat, dt)
+	if err != nil {
+		return err
+	}
+	freqs, power, err := stats.Periodogram(values, dt)
+	if err != nil {
+		return err
+	}
+	periods := stats.DominantPeriods(freqs, power, 3)
+	base := filepath.Base(filename)
+	if PRINT {
+		fmt.Printf("Dominant periods (s) : %.3g %s\n", periods, base)
+	}
+	// Create the plot
+	p, err := plotfunc.NewPlot(fmt.Sprintf("%s\n(dt=%dms)", base, DT), "frequency (Hz)", "power")
+	if err != nil {
+		return err
+	}
+	// the log scale only accepts positive powers
+	var f, pw []float64
+	for i := range power {
+		if power[i] > 0 {
+			f = append(f, freqs[i])
+			pw = append(pw, power[i])
+		}
+	}
+	if len(f) == 0 {
+		return errors.New("Null spectrum for " + filename)
+	}
+	if err = plotfunc.AddWithLineXY(f, pw, "", 0, p); err != nil {
+		return err
+	}
+	pmin, pmax := sliceutil.MinMax(pw)
+	for _, period := range periods {
+		if err = plotfunc.AddVLine(1./period, pmin, pmax, fmt.Sprintf("%.3gs", period), color.Black, p); err != nil {
 			return err
 		}
 	}
+	plotfunc.SetLogX(p)
+	plotfunc.SetLogY(p)
 	// Save the plot to a PNG file.
-	return p.Save(10*vg.Centimeter, 10*vg.Centimeter, confs[0].xlabel+"_throughputs_"+ComparePNGsuffix+".png")
+	return savePlot(p, 15*vg.Centimeter, 10*vg.Centimeter, base+"_spectrum.png")
 }
 
-// Compute the throughput for every dataset and draw it
-// files : files to parse
-// sizes : files corresponding abcissa
-func drawThroughputsFiles(c Config) error {
-	var sizes []float64
-	var err error
-	if c.abscisIsSz {
-		sizes, err = sliceutil.StrToF64(c.abscis)
-		if err != nil {
-			return err
-		}
-	} else {
-		sizes = sliceutil.FillF64(c.kb, len(c.files))
+// Draw a time series together with its mean
+// the coefficient of variation is added to the title
+func drawTimeSeries(x, y []float64, ylabel, title, outPng string) error {
+	mean, cv, err := stats.CoefVariation(y)
+	if err != nil {
+		return err
+	}
+	if PRINT {
+		fmt.Printf("Time series : mean=%.3e cv=%.3e %s %s\n", mean, cv, ylabel, title)
 	}
-	trput, err := computeThoughputFiles(c.files, sizes, c.nbPtsDiscard)
+	title = fmt.Sprintf("%s\n(bucket=%dms mean=%.2f cv=%.2f)", title, BUCKET, mean, cv)
+	// Create the plot
+	p, err := plotfunc.NewPlot(title, "time (s)", ylabel)
 	if err != nil {
 		return err
 	}
-	print(c.abscis, trput, "Throughput")
-	base := filepath.Base(c.root)
-	if isNumDot(c.abscis[0]) {
-		x, err := sliceutil.StrToF64(c.abscis)
-		if err != nil {
-			return err
-		}
-		return drawPointsXY(x, trput, c.xlabel, "nb of Mb / s", base+c.title, base+"_throughputs.png")
-	} else {
-		return drawBar(c.abscis, trput, []string{c.xlabel}, "nb of Mb / s", "Throughput", base+"_throughputs.png")
+	// Add the data
+	if err = plotfunc.AddWithLineXY(x, y, "", 0, p); err != nil {
+		return err
+	}
+	// Add the mean
+	if err = plotfunc.AddHLine(mean, x[0], x[len(x)-1], "", color.Black, p); err != nil {
+		return err
 	}
+	// Save the plot to a PNG file.
+	return savePlot(p, 15*vg.Centimeter, 10*vg.Centimeter, outPng)
 }
 
 // call ParseFile (with the given filename)
@@ -268,10 +1108,81 @@ func drawSlideFile(filename string, nbPtsDiscard int) error {
 	return drawSlide(fvalues, NVAL, nbPtsDiscard, "Msg number", "times (ms)", title, outPng)
 }
 
+// Parse a file and draw the median, p95 and p99 of the sliding windows of NVAL messages
+// (or of TW ms if set) with the p95 and p99 as shaded bands above the median
+// image name = ${filename}_nval${NVAL}_slidequant.png or ${filename}_tw${TW}_slidequant.png
+func drawSlideQuantFile(filename string, nbPtsDiscard int) error {
+	t, lat, err := parseLatencies(filename, nbPtsDiscard)
+	if err != nil {
+		return err
+	}
+	ps := []float64{0.5, 0.95, 0.99}
+	base := filepath.Base(filename)
+	var x []float64
+	var q [][]float64
+	var xlabel, win string
+	if TW > 0 {
+		// the send times are not always sorted
+		idx := make([]int, len(t))
+		for i := range idx {
+			idx[i] = i
+		}
+		sort.SliceStable(idx, func(i, j int) bool { return t[idx[i]] < t[idx[j]] })
+		x = make([]float64, len(t))
+		y := make([]float64, len(t))
+		for i, j := range idx {
+			x[i], y[i] = t[j], lat[j]
+		}
+		if q, err = stats.RollingQuantilesTime(x, y, float64(TW)/1000., ps); err != nil {
+			return err
+		}
+		xlabel, win = "time (s)", fmt.Sprintf("tw%d", TW)
+	} else {
+		if q, err = stats.RollingQuantiles(lat, NVAL, ps); err != nil {
+			return err
+		}
+		// a window is drawn at its last message
+		x = make([]float64, len(q[0]))
+		for i := range x {
+			x[i] = float64(i + NVAL - 1 + nbPtsDiscard)
+		}
+		xlabel, win = "Msg number", fmt.Sprintf("nval%d", NVAL)
+	}
+	// Create the plot
+	p, err := plotfunc.NewPlot(fmt.Sprintf("%s\n(%s)", base, win), xlabel, "times (ms)")
+	if err != nil {
+		return err
+	}
+	if err = plotfunc.AddBand(x, q[0], q[2], "p99", color.NRGBA{B: 255, G: 128, A: 40}, p); err != nil {
+		return err
+	}
+	if err = plotfunc.AddBand(x, q[0], q[1], "p95", color.NRGBA{B: 255, G: 128, A: 100}, p); err != nil {
+		return err
+	}
+	if err = plotfunc.AddWithLineXY(x, q[0], "median", 0, p); err != nil {
+		return err
+	}
+	if PRINT {
+		fmt.Printf("Sliding percentiles : median=%.3e p95=%.3e p99=%.3e (means of the windows) %s\n",
+			stats.MeanF64(q[0]), stats.MeanF64(q[1]), stats.MeanF64(q[2]), base)
+	}
+	// Save the plot to a PNG file.
+	return savePlot(p, 15*vg.Centimeter, 10*vg.Centimeter, fmt.Sprintf("%s_%s_slidequant.png", base, win))
+}
+
 // slide the data with an interval of nval data values
 // for each window, compute the mean and dev
+// the windows containing outliers are highlighted (and excluded from the fit if EXCLUDE is set)
 // draw and save into a PNG image
 func drawSlide(data []float64, nval int, nbPtsDiscard int, xlabel, ylabel, title, outPng string) error {
+	// the outliers are detected without the discarded points, as in cleanData
+	idx, err := detectOutliers(data[nbPtsDiscard:])
+	if err != nil {
+		return err
+	}
+	for i := range idx {
+		idx[i] += nbPtsDiscard
+	}
 	var means, devs, x []float64
 	temp := make([]float64, nval)
 	for i := 0; i < len(data)-nval; i++ {
@@ -286,37 +1197,196 @@ func drawSlide(data []float64, nval int, nbPtsDiscard int, xlabel, ylabel, title
 		devs = append(devs, sdev)
 		x = append(x, float64(i))
 	}
-	return drawLinearFit(x[nbPtsDiscard:], means[nbPtsDiscard:], xlabel, ylabel, title, outPng)
+	// the windows [j - nval + 1, j] contain the outlier j
+	var out []int
+	for w, k := nbPtsDiscard, 0; w < len(x); w++ {
+		for k < len(idx) && idx[k] < w {
+			k++
+		}
+		if k < len(idx) && idx[k] < w+nval {
+			out = append(out, w-nbPtsDiscard)
+		}
+	}
+	return drawLinearFit(x[nbPtsDiscard:], means[nbPtsDiscard:], out, xlabel, ylabel, title, outPng)
+}
+
+// Detect the outliers of the data with the OUTLIERS method
+// Returns the indexes of the outliers, none if OUTLIERS is not set
+func detectOutliers(data []float64) ([]int, error) {
+	if OUTLIERS == "" {
+		return nil, nil
+	}
+	m := map[string]stats.OutlierMethod{"iqr": stats.OutIQR, "mad": stats.OutMAD, "hampel": stats.OutHampel}[OUTLIERS]
+	return stats.Outliers(data, m, OUTK, HAMPELW)
+}
+
+// Detect the outliers of the data with the OUTLIERS method, print their count and first positions if PRINT is set
+// (the message numbers, the data starting at message "offset")
+// Returns the data without the outliers if EXCLUDE is set (else the data) and the indexes of the outliers
+func cleanData(data []float64, offset int, name string) ([]float64, []int, error) {
+	idx, err := detectOutliers(data)
+	if err != nil || len(idx) == 0 {
+		return data, idx, err
+	}
+	if PRINT {
+		// the same file is cleaned by several diagrams
+		pos := make([]int, 0, 10)
+		for _, j := range idx {
+			if len(pos) == cap(pos) {
+				break
+			}
+			pos = append(pos, j+offset)
+		}
+		more := ""
+		if len(idx) > len(pos) {
+			more = " ..."
+		}
+		printOnce("Outliers (%s) : %d / %d at %v%s %s\n", OUTLIERS, len(idx), len(data), pos, more, name)
+	}
+	if EXCLUDE {
+		return stats.RemoveIndexes(data, idx), idx, nil
+	}
+	return data, idx, nil
+}
+
+// Detect the change points of y with the CHANGES method, print them and draw them as vertical lines
+// changes of the mean if slope is false, else changes of the straight line fitting y(x) (knees)
+// sig are the errors of y (nil = estimated from the data), the segments are at least minseg points long
+func addChangePoints(x, y, sig []float64, slope bool, minseg int, name string, p *plot.Plot) error {
+	if CHANGES == "" {
+		return nil
+	}
+	m := map[string]stats.ChangeMethod{"pelt": stats.CpPELT, "binseg": stats.CpBinSeg, "cusum": stats.CpCUSUM}[CHANGES]
+	var xs []float64
+	if slope {
+		xs = x
+	}
+	cps, err := stats.ChangePoints(xs, y, sig, m, CPPEN, minseg)
+	if err != nil {
+		return err
+	}
+	// the change is drawn between the last point of a segment and the first point of the next one
+	pos := make([]float64, len(cps))
+	for i, c := range cps {
+		pos[i] = (x[c-1] + x[c]) / 2.
+	}
+	if slope {
+		fmt.Printf("Change points (%s) : %d knees at %.4g %s\n", CHANGES, len(cps), pos, name)
+	} else {
+		// means of the segments
+		bounds := append(append([]int{0}, cps...), len(y))
+		means := make([]float64, len(bounds)-1)
+		for i := range means {
+			means[i] = stats.MeanF64(y[bounds[i]:bounds[i+1]])
+		}
+		fmt.Printf("Change points (%s) : %d at %.4g segment means %.4g %s\n", CHANGES, len(cps), pos, means, name)
+	}
+	ymin, ymax := sliceutil.MinMax(y)
+	for i := range pos {
+		legend := ""
+		if i == 0 {
+			legend = "change points"
+		}
+		if err = plotfunc.AddVLine(pos[i], ymin, ymax, legend, plotfunc.GREEN, p); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // Parse the filename in the root folder
 // transform the data into millis
 func parseFile(filename string) ([]float64, error) {
-	values, err := parser.ParseAndDiff(filename)
+	_, lat, err := parseLatencies(filename, 0)
+	return lat, err
+}
+
+// Parse the filename, return the send times (s) relative to the first kept message
+// and the latencies (ms) without the first nbPtsDiscard messages
+// the latencies are corrected for the clock skew between the hosts if SKEW is set, and checked
+func parseLatencies(filename string, nbPtsDiscard int) ([]float64, []float64, error) {
+	ts1, ts2, err := parseData(filename)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	t := make([]float64, len(ts1))
+	lat := make([]float64, len(ts1))
+	for i := range ts1 {
+		t[i] = float64(ts1[i]-ts1[0]) / 1.e9
+		lat[i] = float64(ts2[i]-ts1[i]) / 1.e6
+	}
+	base := filepath.Base(filename)
+	// the skew is estimated on all the messages
+	if SKEW && len(t) > 0 {
+		offset, drift, err := stats.MinDelayEnvelope(t, lat)
+		if err != nil {
+			return nil, nil, err
+		}
+		if PRINT {
+			printOnce("Clock skew : offset=%.3ems drift=%.3eppm %s\n", offset, drift*1000., base)
+		}
+		for i := range lat {
+			lat[i] -= offset + drift*t[i]
+		}
+	}
+	checkLatencies(lat, base)
+	t, lat = t[nbPtsDiscard:], lat[nbPtsDiscard:]
+	if len(t) > 0 {
+		t0 := t[0]
+		for i := range t {
+			t[i] -= t0
+		}
+	}
+	return t, lat, nil
+}
+
+// Print a warning if some latencies are negative or greater than MAXLAT
+// once per file, as the latencies of a file are parsed by several diagrams
+func checkLatencies(lat []float64, name string) {
+	var nneg, nbig int
+	min, max := 0., 0.
+	for _, l := range lat {
+		if l < 0 {
+			nneg++
+			min = math.Min(min, l)
+		}
+		if l > MAXLAT {
+			nbig++
+			max = math.Max(max, l)
+		}
+	}
+	if nneg > 0 {
+		printOnce("Warning : %d negative latencies (min=%.3ems), the clocks may be skewed (option -skew) %s\n", nneg, min, name)
+	}
+	if nbig > 0 {
+		printOnce("Warning : %d latencies above %gms (max=%.3ems) %s\n", nbig, MAXLAT, max, name)
 	}
-	// Transform nano into milli
-	fvalues := sliceutil.MapF64(sliceutil.I64ToF64(values), func(x float64) float64 { return x / 1000000. })
-	return fvalues, nil
 }
 
-// call parseFile and drawHisto
+// call parseFile and drawHisto with the histogram settings of the config
 // image name = ${filename}_histo.png
-func drawHistoFile(filename string, nbPtsDiscard int) error {
+func drawHistoFile(c Config, filename string) error {
 	fvalues, err := parseFile(filename)
 	if err != nil {
 		return err
 	}
-	return drawHisto(fvalues, filepath.Base(filename), filepath.Base(filename)+"_histo.png", nbPtsDiscard)
+	return drawHisto(fvalues, filepath.Base(filename), filepath.Base(filename)+"_histo.png", c.nbPtsDiscard, c.histo)
 }
 
 // Draw a normalized histogram
-// compare with its gaussian function
+// compare with the normal distribution or with the best distribution fitted by maximum likelihood if DIST is set
+// the columns and the density estimation follow the settings h, all are computed from the cleaned data
 // save the plot to PNG image file (name is filename_histo.png)
-func drawHisto(data []float64, title, outPng string, nbPtsDiscard int) error {
+func drawHisto(data []float64, title, outPng string, nbPtsDiscard int, h Histo) error {
+	clean, _, err := cleanData(data[nbPtsDiscard:], nbPtsDiscard, title)
+	if err != nil {
+		return err
+	}
 	// Compute the moments
-	mean, adev, sdev, skew, curt, err := stats.Moments(data[nbPtsDiscard:])
+	mean, adev, sdev, skew, curt, err := stats.Moments(clean)
+	if err != nil {
+		return err
+	}
 	if PRINT {
 		fmt.Printf("Moments : mean=%.3e adev=%.3e sdev=%.3e skew=%.3e curt=%.3e %s\n", mean, adev, sdev, skew, curt, title)
 	}
@@ -325,32 +1395,80 @@ func drawHisto(data []float64, title, outPng string, nbPtsDiscard int) error {
 	if err != nil {
 		return err
 	}
-	// Draw an histogram
-	v := make(plotter.Values, len(data[nbPtsDiscard:]))
-	for i := range v {
-		v[i] = data[i+nbPtsDiscard]
+	// Draw an histogram with NCOL bins or the number of bins given by the bins rule
+	nb := NCOL
+	if h.bins != "" {
+		rule, err := h.binRule()
+		if err != nil {
+			return err
+		}
+		nb = stats.NumBins(clean, rule)
+	}
+	min, max := sliceutil.MinMax(clean)
+	if min == max {
+		min, max = min-0.5, max+0.5
+	}
+	var edges []float64
+	if h.logbins {
+		if min <= 0 {
+			return errors.New("Log bins need positive latencies in " + title)
+		}
+		edges, err = stats.LogBins(min, max, nb)
+		plotfunc.SetLogX(p)
+	} else {
+		edges, err = stats.LinearBins(min, max, nb)
 	}
-	h, err := plotter.NewHist(v, NCOL)
 	if err != nil {
 		return err
 	}
-	h.Normalize(1)
-	p.Add(h)
-	// Add the normal distribution function
-	plotfunc.AddGaussian(mean, sdev, p)
+	if err = plotfunc.AddHistoEdges(edges, stats.ToHistoEdges(clean, edges), p); err != nil {
+		return err
+	}
+	if PRINT {
+		fmt.Printf("Histogram : bins=%d rule=%s log=%t %s\n", nb, h.bins, h.logbins, title)
+	}
+	// Add the kernel density estimation
+	if h.kernel != "" {
+		x, _ := stats.LinearBins(min, max, 200)
+		if h.logbins {
+			x, _ = stats.LogBins(min, max, 200)
+		}
+		dens, bw, err := kernelDensity(clean, x, h)
+		if err != nil {
+			return err
+		}
+		if err = plotfunc.AddWithLineXY(x, dens, fmt.Sprintf("kde %s h=%.2g", h.kernel, bw), 3, p); err != nil {
+			return err
+		}
+	}
+	// Add the best fitted distribution function, the fits of the shifted distributions are costly
+	var fits []stats.FitResult
+	if DIST {
+		fits = stats.FitAll(clean)
+	}
+	if len(fits) == 0 {
+		plotfunc.AddGaussian(mean, sdev, p)
+	} else {
+		plotfunc.AddDistribution(fits[0].Dist, fits[0].Dist.Name(), p)
+	}
+	if PRINT {
+		for _, f := range fits {
+			fmt.Printf("Fit : %-20s loglik=%.3e aic=%.3e bic=%.3e ks=%.3e ad=%.3e %s\n", f.Dist.Name(), f.LogLik, f.AIC, f.BIC, f.KS, f.AD, title)
+		}
+	}
 	// Save the plot to a PNG file.
-	return p.Save(15*vg.Centimeter, 15*vg.Centimeter, outPng)
+	return savePlot(p, 15*vg.Centimeter, 15*vg.Centimeter, outPng)
 }
 
 // Comparison of means with deviations for different configs
-func compareMeansErr(confs []Config) error {
+func compareMeansErr(ctx context.Context, confs []Config) error {
 	// Create the plot
-	p, err := plotfunc.NewPlot("Means", confs[0].xlabel, "times (ms)")
+	p, err := plotfunc.NewPlot("Means ("+errsLegend()+")", confs[0].xlabel, "times (ms)")
 	if err != nil {
 		return err
 	}
 	for i, c := range confs {
-		means, devs, err := computeMeansErrFiles(c.files, c.nbPtsDiscard)
+		means, lows, highs, err := computeMeansErrFiles(ctx, c.files, c.nbPtsDiscard)
 		if err != nil {
 			return err
 		}
@@ -359,23 +1477,23 @@ func compareMeansErr(confs []Config) error {
 		if err != nil {
 			return err
 		}
-		if err = plotfunc.AddWithErrXY(x, means, devs, c.legend(), i, p); err != nil {
+		if err = plotfunc.AddWithAsymErrXY(x, means, lows, highs, c.legend(), i, p); err != nil {
 			return err
 		}
 	}
 	// Save the plot to a PNG file.
-	return p.Save(10*vg.Centimeter, 10*vg.Centimeter, confs[0].xlabel+"_meansErr_"+ComparePNGsuffix+".png")
+	return savePlot(p, 10*vg.Centimeter, 10*vg.Centimeter, confs[0].xlabel+"_meansErr_"+ComparePNGsuffix+".png")
 }
 
 // Comparison of means for different configs
-func compareMeansLine(confs []Config) error {
+func compareMeansLine(ctx context.Context, confs []Config) error {
 	// Create the plot
 	p, err := plotfunc.NewPlot("Means", confs[0].xlabel, "times (ms)")
 	if err != nil {
 		return err
 	}
 	for i, c := range confs {
-		means, _, err := computeMeansErrFiles(c.files, c.nbPtsDiscard)
+		means, _, _, err := computeMeansErrFiles(ctx, c.files, c.nbPtsDiscard)
 		if err != nil {
 			return err
 		}
@@ -389,37 +1507,151 @@ func compareMeansLine(confs []Config) error {
 		}
 	}
 	// Save the plot to a PNG file.
-	return p.Save(10*vg.Centimeter, 10*vg.Centimeter, confs[0].xlabel+"_means_"+ComparePNGsuffix+".png")
+	return savePlot(p, 10*vg.Centimeter, 10*vg.Centimeter, confs[0].xlabel+"_means_"+ComparePNGsuffix+".png")
 }
 
-// Compute the means and deviations for each file
-func computeMeansErrFiles(files []string, nbPtsDiscard int) ([]float64, []float64, error) {
+// Compute the means and their errors for each file
+// the errors are either the standard errors (naive, corrected by the effective sample size or by batch means)
+// or the bootstrap confidence intervals according to ERRS
+// Returns the means, the lower and the upper errors
+func computeMeansErrFiles(ctx context.Context, files []string, nbPtsDiscard int) ([]float64, []float64, []float64, error) {
 	means := make([]float64, len(files))
-	devs := make([]float64, len(files))
+	lows := make([]float64, len(files))
+	highs := make([]float64, len(files))
 	for i, f := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, nil, err
+		}
 		fvalues, err := parseFile(f)
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, nil, err
 		}
-		mean, adev, sdev, skew, curt, err := stats.Moments(fvalues[nbPtsDiscard:])
+		data, _, err := cleanData(fvalues[nbPtsDiscard:], nbPtsDiscard, filepath.Base(f))
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, nil, err
+		}
+		mean, adev, sdev, skew, curt, err := stats.Moments(data)
+		if err != nil {
+			return nil, nil, nil, err
 		}
 		if PRINT {
 			fmt.Printf("Moments : mean=%.3e adev=%.3e sdev=%.3e skew=%.3e curt=%.3e %s\n", mean, adev, sdev, skew, curt, filepath.Base(f))
 		}
 		means[i] = mean
-		devs[i] = sdev / math.Sqrt(float64(len(fvalues)))
+		switch ERRS {
+		case "stderr":
+			lows[i] = sdev / math.Sqrt(float64(len(data)))
+		case "ess":
+			ess, err := stats.EffectiveSampleSize(data, MAXLAG)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			if PRINT {
+				fmt.Printf("Effective sample size : n=%d ess=%.3e %s\n", len(data), ess, filepath.Base(f))
+			}
+			lows[i] = sdev / math.Sqrt(ess)
+		case "batch":
+			_, stderr, err := stats.BatchMeans(data, int(math.Sqrt(float64(len(data)))))
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			lows[i] = stderr
+		default:
+			_, lo, hi, err := bootstrap(data, stats.MeanF64)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			lows[i], highs[i] = mean-lo, hi-mean
+			if PRINT {
+				if err = printBootstrapCIs(f, data, nbPtsDiscard); err != nil {
+					return nil, nil, nil, err
+				}
+			}
+			continue
+		}
+		highs[i] = lows[i]
+	}
+	return means, lows, highs, nil
+}
+
+// Compute the statistic and its 95% confidence interval with the plain or block bootstrap according to ERRS
+func bootstrap(data []float64, stat stats.Statistic) (float64, float64, float64, error) {
+	// fixed seed to get reproducible plots
+	r := rand.New(rand.NewSource(1))
+	if ERRS == "block" {
+		return stats.BlockBootstrap(data, stats.BlockLength(len(data)), stat, NBOOT, 0.05, r)
+	}
+	return stats.Bootstrap(data, stat, NBOOT, 0.05, r)
+}
+
+// Legend of the error bars of the means according to ERRS
+// standard errors (68% coverage for normal means) or 95% bootstrap confidence intervals
+func errsLegend() string {
+	switch ERRS {
+	case "stderr":
+		return "mean ± stderr"
+	case "ess":
+		return "mean ± stderr (ess)"
+	case "batch":
+		return "mean ± stderr (batch means)"
+	case "block":
+		return "mean, 95% block bootstrap CI"
+	default:
+		return "mean, 95% bootstrap CI"
+	}
+}
+
+// Return the standard errors of the means from their error bars (from y - low to y + high)
+// the bootstrap 95% confidence intervals are converted assuming normal means : half width / 1.96
+func stdErrs(low, high []float64) []float64 {
+	z := 1.
+	if ERRS == "boot" || ERRS == "block" {
+		z = stats.Normal{Mu: 0, Sigma: 1}.Quantile(0.975)
+	}
+	sig := make([]float64, len(low))
+	for i := range sig {
+		sig[i] = (low[i] + high[i]) / (2. * z)
+	}
+	return sig
+}
+
+// Print the bootstrap confidence intervals of the median, p99 and throughput (nb of msg / s) of the file
+func printBootstrapCIs(filename string, values []float64, nbPtsDiscard int) error {
+	for _, q := range []float64{0.5, 0.99} {
+		v, lo, hi, err := bootstrap(values, stats.Percentile(q))
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Bootstrap : p%g=%.3e [%.3e, %.3e] %s\n", 100*q, v, lo, hi, filepath.Base(filename))
+	}
+	// the throughput is the inverse of the mean gap between received messages
+	_, ts2, err := parseData(filename)
+	if err != nil {
+		return err
 	}
-	return means, devs, nil
+	ts := sliceutil.I64ToF64(ts2[nbPtsDiscard:])
+	if len(ts) < 2 {
+		return fmt.Errorf("no throughput with %d messages after the %d discarded in %s", len(ts), nbPtsDiscard, filepath.Base(filename))
+	}
+	sort.Float64s(ts)
+	gaps := make([]float64, len(ts)-1)
+	for i := range gaps {
+		gaps[i] = (ts[i+1] - ts[i]) / 1.e9
+	}
+	v, lo, hi, err := bootstrap(gaps, stats.MeanF64)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Bootstrap : msg/s=%.3e [%.3e, %.3e] %s\n", 1./v, 1./hi, 1./lo, filepath.Base(filename))
+	return nil
 }
 
 // Parse each file of suffixes
 // compute the means and draw it with the error bars
 // save the plot to a PNG file
-func drawMeansErrFiles(c Config) error {
+func drawMeansErrFiles(ctx context.Context, c Config) error {
 	base := filepath.Base(c.root)
-	means, devs, err := computeMeansErrFiles(c.files, c.nbPtsDiscard)
+	means, lows, highs, err := computeMeansErrFiles(ctx, c.files, c.nbPtsDiscard)
 	if err != nil {
 		return err
 	}
@@ -428,50 +1660,99 @@ func drawMeansErrFiles(c Config) error {
 		if err != nil {
 			return err
 		}
-		return drawErrsXY(x, means, devs, c.xlabel, "times (ms)", base+c.title, base+"_mean_err.png")
+		return drawErrsXY(x, means, lows, highs, c.xlabel, "times (ms)", base+c.title, base+"_mean_err.png")
 	} else {
 		return drawBar(c.abscis, means, []string{c.xlabel}, "times (ms)", "Mean latency", base+"_mean_err.png")
 	}
 }
 
-// Draw the x,y  for every dataset with deviation as Y error bars
-func drawErrsXY(x, y, devs []float64, xlabel, ylabel, title, outPng string) error {
+// Draw the x,y  for every dataset with deviation as Y error bars (from y - low to y + high)
+// the changes of slope and the polynomial fits are weighted by the standard errors deduced from the bars (see stdErrs)
+func drawErrsXY(x, y, low, high []float64, xlabel, ylabel, title, outPng string) error {
 	// Create the plot
 	p, err := plotfunc.NewPlot(title, xlabel, ylabel)
 	if err != nil {
 		return err
 	}
 	// Add the means with errors
-	plotfunc.AddWithErrXY(x, y, devs, "", 0, p)
+	if err = plotfunc.AddWithAsymErrXY(x, y, low, high, errsLegend(), 0, p); err != nil {
+		return err
+	}
+	// Add the knees of the sweep (changes of slope) and the polynom, weighted by the standard errors if they are all known
+	sig := stdErrs(low, high)
+	for i := range sig {
+		if sig[i] <= 0 {
+			sig = nil
+			break
+		}
+	}
+	if err = addChangePoints(x, y, sig, true, 2, title, p); err != nil {
+		return err
+	}
 
 	// a, b, siga, sigb, chi2, sigdat := plotfunc.AddLinearfit(x[1:], y[1:], p)
 	// if PRINT {
 	// 	fmt.Println("drawMeansErr moments", a, b, siga, sigb, chi2, sigdat)
 	// }
 
-	// Add regression trinome
-	// coefs, err := plotfunc.AddPolyfit(x, y, devs, 3, p)
-	// if err != nil {
-	// 	return err
-	// }
-	// if PRINT {
-	// 	fmt.Println("drawMeansErr poly coefs", coefs)
-	// }
+	// Add the regression polynom of the best degree
+	if POLYDEG > 0 {
+		f, err := stats.SelectPoly(x, y, sig, POLYDEG, CRIT)
+		if err != nil {
+			return err
+		}
+		if PRINT {
+			fmt.Printf("Poly fit : degree=%d coefs=%.3e center=%.3e scale=%.3e r2=%.3e adjr2=%.3e aic=%.3e cv=%.3e %s\n",
+				f.Degree, f.Coefs, f.Center, f.Scale, f.R2, f.AdjR2, f.AIC, f.CV, title)
+		}
+		xmin, xmax := sliceutil.MinMax(x)
+		if err = plotfunc.AddPolyBands(f, xmin, xmax, p); err != nil {
+			return err
+		}
+		if err = drawResiduals(x, f.Residuals, xlabel, title, strings.TrimSuffix(outPng, ".png")+"_residuals.png"); err != nil {
+			return err
+		}
+	}
+	// Save the plot to a PNG file.
+	return savePlot(p, 10*vg.Centimeter, 10*vg.Centimeter, outPng)
+}
+
+// Draw the residuals of a fit
+func drawResiduals(x, res []float64, xlabel, title, outPng string) error {
+	// Create the plot
+	p, err := plotfunc.NewPlot(title+"\nresiduals", xlabel, "residuals")
+	if err != nil {
+		return err
+	}
+	if err = plotfunc.AddWithPointsXY(x, res, "", 0, p); err != nil {
+		return err
+	}
+	xmin, xmax := sliceutil.MinMax(x)
+	if err = plotfunc.AddHLine(0, xmin, xmax, "", color.Black, p); err != nil {
+		return err
+	}
 	// Save the plot to a PNG file.
-	return p.Save(10*vg.Centimeter, 10*vg.Centimeter, outPng)
+	return savePlot(p, 10*vg.Centimeter, 10*vg.Centimeter, outPng)
 }
 
 // Compute the means for every dataset and draw it
 // Compute the linear regression that fits the means and draw it
-func drawMeansFiles(c Config) error {
+func drawMeansFiles(ctx context.Context, c Config) error {
 	var means []float64
 	// Parse the files and compute the means
 	for _, f := range c.files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		values, err := parseFile(f)
 		if err != nil {
 			return err
 		}
-		ave, adev, sdev, skew, curt, err := stats.Moments(values[c.nbPtsDiscard:])
+		data, _, err := cleanData(values[c.nbPtsDiscard:], c.nbPtsDiscard, filepath.Base(f))
+		if err != nil {
+			return err
+		}
+		ave, adev, sdev, skew, curt, err := stats.Moments(data)
 		if PRINT {
 			fmt.Printf("Moments : mean=%.3e adev=%.3e sdev=%.3e skew=%.3e curt=%.3e %s\n", ave, adev, sdev, skew, curt, c.title)
 		}
@@ -483,13 +1764,14 @@ func drawMeansFiles(c Config) error {
 		if err != nil {
 			return err
 		}
-		return drawLinearFit(x, means, c.xlabel, "times (ms)", base+c.title, base+"_mean.png")
+		return drawLinearFit(x, means, nil, c.xlabel, "times (ms)", base+c.title, base+"_mean.png")
 	} else {
 		return drawBar(c.abscis, means, []string{c.xlabel}, "times (ms)", "Mean latency", base+"_mean.png")
 	}
 }
 
 // Draw the data
+// and the nonlinear model NLFIT that fits the data
 func drawPointsXY(x, y []float64, xlabel, ylabel, title, outPng string) error {
 	// Create the plot
 	p, err := plotfunc.NewPlot(title, xlabel, ylabel)
@@ -500,8 +1782,38 @@ func drawPointsXY(x, y []float64, xlabel, ylabel, title, outPng string) error {
 	if err = plotfunc.AddWithPointsXY(x, y, "", 0, p); err != nil {
 		return err
 	}
+	// Add the nonlinear fit, the points are drawn even if it fails
+	if model, a := nlfitModel(x, y); model != nil {
+		chi2, covar, err := plotfunc.AddNonLinearfit(x, y, sliceutil.FillF64(1., len(x)), a, model, p)
+		if err != nil {
+			fmt.Printf("Warning : nonlinear fit %s failed (%v) %s\n", NLFIT, err, title)
+		} else if PRINT {
+			// the points have unit sigmas : the covariance is scaled by the variance of the residuals chi2 / (n - p)
+			siga := sliceutil.FillF64(math.NaN(), len(a))
+			if dof := len(x) - len(a); dof > 0 {
+				for i := range siga {
+					siga[i] = math.Sqrt(covar[i][i] * chi2 / float64(dof))
+				}
+			}
+			fmt.Printf("Nonlinear fit %s : a=%.3e siga=%.3e chi2=%.3e %s\n", NLFIT, a, siga, chi2, title)
+		}
+	}
 	// Save the plot to a PNG file.
-	return p.Save(10*vg.Centimeter, 10*vg.Centimeter, outPng)
+	return savePlot(p, 10*vg.Centimeter, 10*vg.Centimeter, outPng)
+}
+
+// Return the nonlinear model NLFIT and the initial guesses of its parameters for the data
+// or nil if no model is set
+func nlfitModel(x, y []float64) (stats.Fmodel, []float64) {
+	xmin, xmax := sliceutil.MinMax(x)
+	ymin, ymax := sliceutil.MinMax(y)
+	switch NLFIT {
+	case "saturation": // y = a0 (1 - exp(-x / a1))
+		return stats.FSaturation, []float64{ymax, (xmin + xmax) / 4.}
+	case "inverse": // y = a0 + a1 / x
+		return stats.FInverse, []float64{ymin, (ymax - ymin) * xmin}
+	}
+	return nil, nil
 }
 
 // Draw the data as barchart
@@ -516,12 +1828,13 @@ func drawBar(x []string, y []float64, xlabel []string, ylabel, title, outPng str
 		return err
 	}
 	// Save the plot to a PNG file.
-	return p.Save(10*vg.Centimeter, 10*vg.Centimeter, outPng)
+	return savePlot(p, 10*vg.Centimeter, 10*vg.Centimeter, outPng)
 }
 
 // Draw the data and
 // compute the linear regression that fits the data
-func drawLinearFit(x, means []float64, xlabel, ylabel, title, outPng string) error {
+// the points at the sorted indexes "outliers" are highlighted, and excluded from the fit if EXCLUDE is set
+func drawLinearFit(x, means []float64, outliers []int, xlabel, ylabel, title, outPng string) error {
 	// Create the plot
 	p, err := plotfunc.NewPlot(title, xlabel, ylabel)
 	if err != nil {
@@ -531,13 +1844,26 @@ func drawLinearFit(x, means []float64, xlabel, ylabel, title, outPng string) err
 	if err = plotfunc.AddWithPointsXY(x, means, "", 0, p); err != nil {
 		return err
 	}
+	if len(outliers) > 0 {
+		ox := make([]float64, len(outliers))
+		oy := make([]float64, len(outliers))
+		for i, j := range outliers {
+			ox[i], oy[i] = x[j], means[j]
+		}
+		if err = plotfunc.AddOutliers(ox, oy, "outliers", p); err != nil {
+			return err
+		}
+		if EXCLUDE {
+			x, means = stats.RemoveIndexes(x, outliers), stats.RemoveIndexes(means, outliers)
+		}
+	}
 	// Add a regression line
 	a, b, siga, sigb, chi2, sigdat := plotfunc.AddLinearfit(x[1:], means[1:], p)
 	if PRINT {
 		fmt.Printf("Linear fit : a=%.3e b=%.3e siga=%.3e sigb=%.3e chi2=%.3e sigdat=%.3e %s\n", a, b, siga, sigb, chi2, sigdat, title)
 	}
 	// Save the plot to a PNG file.
-	return p.Save(10*vg.Centimeter, 10*vg.Centimeter, outPng)
+	return savePlot(p, 10*vg.Centimeter, 10*vg.Centimeter, outPng)
 }
 
 // Parse a file and draw the data and some stats
@@ -557,14 +1883,37 @@ func drawFile(filename string, nbPtsDiscard int) error {
 	if err = plotfunc.AddWithPoints(fvalues, base, 0, p); err != nil {
 		return err
 	}
+	// Highlight the outliers
+	data, idx, err := cleanData(fvalues[nbPtsDiscard:], nbPtsDiscard, base)
+	if err != nil {
+		return err
+	}
+	if len(idx) > 0 {
+		x := make([]float64, len(idx))
+		y := make([]float64, len(idx))
+		for i, j := range idx {
+			x[i], y[i] = float64(j+nbPtsDiscard), fvalues[j+nbPtsDiscard]
+		}
+		if err = plotfunc.AddOutliers(x, y, "outliers", p); err != nil {
+			return err
+		}
+	}
+	// Add the regime shifts
+	x := make([]float64, len(fvalues)-nbPtsDiscard)
+	for i := range x {
+		x[i] = float64(i + nbPtsDiscard)
+	}
+	if err = addChangePoints(x, fvalues[nbPtsDiscard:], nil, false, CPMIN, base, p); err != nil {
+		return err
+	}
 	// Compute mean regression
-	ave, adev, sdev, skew, curt, err := stats.Moments(fvalues[nbPtsDiscard:])
+	ave, adev, sdev, skew, curt, err := stats.Moments(data)
 	if PRINT {
 		fmt.Printf("Moments : ave=%.3e adev=%.3e sdev=%.3e skew=%.3e curt=%.3e %s\n", ave, adev, sdev, skew, curt, filepath.Base(filename))
 	}
 	plotfunc.AddHLine(ave, float64(nbPtsDiscard), float64(len(fvalues)), "", color.Black, p)
 	// Save the plot to a PNG file.
-	return p.Save(10*vg.Centimeter, 10*vg.Centimeter, filepath.Base(filename)+".png")
+	return savePlot(p, 10*vg.Centimeter, 10*vg.Centimeter, filepath.Base(filename)+".png")
 }
 
 // Returns true if the string is a number, either int or float (very fast)
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+)
+
+// Go on with the other diagrams and files after a failure (option -keep-going), else stop at the first failure
+var KEEPGOING = false
+
+// Failure of a diagram of a config (or of a comparison group), or of one of its data files
+type DrawError struct {
+	Config  string // name of the config or of the comparison group
+	File    string // data file, empty if the diagram is drawn from all files of the config
+	Diagram string // name of the diagram type
+	Err     error
+}
+
+func (e *DrawError) Error() string {
+	if e.File == "" {
+		return fmt.Sprintf("%s : %s : %v", e.Config, e.Diagram, e.Err)
+	}
+	return fmt.Sprintf("%s : %s : %s : %v", e.Config, filepath.Base(e.File), e.Diagram, e.Err)
+}
+
+func (e *DrawError) Unwrap() error {
+	return e.Err
+}
+
+// Failures of a run
+type Errors []error
+
+func (es Errors) Error() string {
+	if len(es) == 1 {
+		return es[0].Error()
+	}
+	return fmt.Sprintf("%d failures, the first one : %v", len(es), es[0])
+}
+
+// Add the error (or the errors of a list) if not nil
+// return true if the processing must stop : failure without KEEPGOING
+func (es *Errors) add(err error) bool {
+	switch e := err.(type) {
+	case nil:
+		return false
+	case Errors:
+		*es = append(*es, e...)
+	default:
+		*es = append(*es, err)
+	}
+	return !KEEPGOING
+}
+
+// Return the list as an error, nil if empty
+func (es Errors) err() error {
+	if len(es) == 0 {
+		return nil
+	}
+	return es
+}
+
+// Call f and return its panic as an error
+func protect(f func() error) (err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = fmt.Errorf("panic: %v", e)
+		}
+	}()
+	return f()
+}
+
+// Call the drawing function, its error or panic is returned as a DrawError
+func drawProtected(config, file, diagram string, f func() error) error {
+	if err := protect(f); err != nil {
+		return &DrawError{Config: config, File: file, Diagram: diagram, Err: err}
+	}
+	return nil
+}
+
+// Print the failures of the run grouped by config
+func printErrors(err error) {
+	es, ok := err.(Errors)
+	if !ok {
+		es = Errors{err}
+	}
+	byConfig := make(map[string][]*DrawError)
+	var names []string
+	var others []error
+	nb := 0
+	for _, e := range es {
+		if errors.Is(e, context.Canceled) {
+			// the interruption is reported by runTasks
+			continue
+		}
+		nb++
+		de, ok := e.(*DrawError)
+		if !ok {
+			others = append(others, e)
+			continue
+		}
+		if _, found := byConfig[de.Config]; !found {
+			names = append(names, de.Config)
+		}
+		byConfig[de.Config] = append(byConfig[de.Config], de)
+	}
+	sort.Strings(names)
+	if nb == 0 {
+		return
+	}
+	fmt.Printf("%d failures:\n", nb)
+	for _, name := range names {
+		fmt.Println(" ", name)
+		for _, de := range byConfig[name] {
+			file := "(all files)"
+			if de.File != "" {
+				file = filepath.Base(de.File)
+			}
+			fmt.Printf("    %-35s %-15s %v\n", file, de.Diagram, de.Err)
+		}
+	}
+	for _, e := range others {
+		fmt.Println(" ", e)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// Without KEEPGOING the first failure stops the processing, the nil errors are ignored and the lists are flattened
+func TestErrorsAdd(t *testing.T) {
+	defer func(k bool) { KEEPGOING = k }(KEEPGOING)
+	e1, e2, e3 := errors.New("1"), errors.New("2"), errors.New("3")
+	for _, c := range []struct {
+		keepGoing bool
+		err       error
+		stop      bool
+		wanted    Errors
+	}{
+		{false, nil, false, nil},
+		{true, nil, false, nil},
+		{false, e3, true, Errors{e1, e3}},
+		{true, e3, false, Errors{e1, e3}},
+		{false, Errors{e2, e3}, true, Errors{e1, e2, e3}},
+		{true, Errors{e2, e3}, false, Errors{e1, e2, e3}},
+	} {
+		KEEPGOING = c.keepGoing
+		es := Errors{e1}
+		if c.wanted == nil {
+			c.wanted = es
+		}
+		if stop := es.add(c.err); stop != c.stop || !reflect.DeepEqual(es, c.wanted) {
+			t.Errorf("Bad add of %v with keep-going=%t: wanted: %t %v found: %t %v", c.err, c.keepGoing, c.stop, c.wanted, stop, es)
+		}
+	}
+	if err := (Errors{}).err(); err != nil {
+		t.Errorf("Error of an empty list: %v", err)
+	}
+}
+
+// A panic of a drawing is returned as a failure of its diagram
+func TestDrawProtected(t *testing.T) {
+	err := drawProtected("c", "/d/f1", "hist", func() error { panic("boom") })
+	de, ok := err.(*DrawError)
+	if !ok || de.Config != "c" || de.File != "/d/f1" || de.Diagram != "hist" || de.Err.Error() != "panic: boom" {
+		t.Errorf("Bad error of a panic: %#v", err)
+	}
+	if err = drawProtected("c", "", "box", func() error { return nil }); err != nil {
+		t.Errorf("Error of a successful drawing: %v", err)
+	}
+	err = drawProtected("c", "", "box", func() error { return context.Canceled })
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Interruption not found in %v", err)
+	}
+}
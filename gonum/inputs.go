@@ -1,5 +1,41 @@
 package main
 
+// The groups of configs compared one each other (command compare)
+var CompareGroups = []CompareGroup{
+	{
+		name:    "queueBufMaxMs_100k",
+		configs: []string{"p6_queueBufMaxMs_100k", "p36_queueBufMaxMs_100k", "p72_queueBufMaxMs_100k", "p108_queueBufMaxMs_100k", "p180_queueBufMaxMs_100k", "p360_queueBufMaxMs_100k"},
+	},
+	{
+		name:    "queuedMinMessages_100k",
+		configs: []string{"p6_queuedMinMessages_100k", "p36_queuedMinMessages_100k", "p72_queuedMinMessages_100k", "p108_queuedMinMessages_100k", "p180_queuedMinMessages_100k", "p360_queuedMinMessages_100k"},
+	},
+	{
+		name:    "queueBufMaxMsg_100k",
+		configs: []string{"p6_queueBufMaxMsg_100k", "p36_queueBufMaxMsg_100k", "p72_queueBufMaxMsg_100k", "p108_queueBufMaxMsg_100k", "p180_queueBufMaxMsg_100k", "p360_queueBufMaxMsg_100k"},
+	},
+	{
+		name:    "batchNumMsg_100k",
+		configs: []string{"p6_batchNumMsg_100k", "p36_batchNumMsg_100k", "p72_batchNumMsg_100k", "p108_batchNumMsg_100k", "p180_batchNumMsg_100k", "p360_batchNumMsg_100k"},
+	},
+	{
+		name:    "fetchMinBytes_100k",
+		configs: []string{"p6_fetchMinBytes_100k", "p36_fetchMinBytes_100k", "p72_fetchMinBytes_100k", "p108_fetchMinBytes_100k", "p180_fetchMinBytes_100k", "p360_fetchMinBytes_100k"},
+	},
+	{
+		name:    "fetchWaitMaxMs_100k",
+		configs: []string{"p6_fetchWaitMaxMs_100k", "p36_fetchWaitMaxMs_100k", "p72_fetchWaitMaxMs_100k", "p108_fetchWaitMaxMs_100k", "p180_fetchWaitMaxMs_100k", "p360_fetchWaitMaxMs_100k"},
+	},
+	{
+		name:    "queueBufMaxKbytes_100k",
+		configs: []string{"p6_queueBufMaxKbytes_100k", "p36_queueBufMaxKbytes_100k", "p72_queueBufMaxKbytes_100k", "p108_queueBufMaxKbytes_100k", "p180_queueBufMaxKbytes_100k", "p360_queueBufMaxKbytes_100k"},
+	},
+	{
+		name:    "msgSize",
+		configs: []string{"p6_msgSize", "p36_msgSize", "p72_msgSize", "p108_msgSize", "p180_msgSize", "p360_msgSize"},
+	},
+}
+
 var Configs = []Config{
 	{
 		name:         "msgSizeAck1",
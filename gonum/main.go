@@ -1,13 +1,17 @@
 package main
 
 import (
+	"context"
 	"errors"
-	"flag"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"plots/plotfunc"
-	"sync"
+	"plots/stats"
+	"strconv"
+	"strings"
+	"syscall"
 )
 
 // Definition of a Config fields
@@ -22,11 +26,79 @@ type Config struct {
 	abscisIsSz   bool     // [optional] true if the size of the messages is represented by the absissa, needed to compute the throughput (default false)
 	title        string   // [optional] Add a title line (default is empty)
 	kb           float64  // [optional] default size of the messages in Mb (default = 0.1)
+	histo        Histo    // [optional] settings of the histograms (default the options -bins, -logbins, -kde and -bw)
 
 	files  []string // real file names (root + prefix + sufix + postfix), computed automatically
 	abscis []string // corresponding abscissa of the data files, in the correct unit. If empty, it is deduced from the sufix
 }
 
+// Settings of the histograms and of the density estimations of a config
+type Histo struct {
+	bins    string // rule of the number of columns : fd, scott or sturges (NCOL columns if empty)
+	logbins bool   // logarithmically spaced columns
+	kernel  string // kernel of the density estimation : gauss or epanechnikov (no estimation drawn if empty)
+	bw      string // bandwidth rule of the density estimation : silverman or sj
+}
+
+// Return the rule of the number of columns, an error if the rule is unknown or not set
+func (h Histo) binRule() (stats.BinRule, error) {
+	switch h.bins {
+	case stats.BinFD.String():
+		return stats.BinFD, nil
+	case stats.BinScott.String():
+		return stats.BinScott, nil
+	case stats.BinSturges.String():
+		return stats.BinSturges, nil
+	}
+	return 0, errors.New("unknown bin rule " + h.bins)
+}
+
+// Return the kernel of the density estimation (gauss if not set), an error if the kernel is unknown
+func (h Histo) densityKernel() (stats.Kernel, error) {
+	switch h.kernel {
+	case "", stats.KGauss.String():
+		return stats.KGauss, nil
+	case stats.KEpanechnikov.String():
+		return stats.KEpanechnikov, nil
+	}
+	return 0, errors.New("unknown kernel " + h.kernel)
+}
+
+// Return the bandwidth of the density estimation of the data, an error if the bandwidth rule is unknown
+func (h Histo) bandwidth(data []float64) (float64, error) {
+	switch h.bw {
+	case "silverman":
+		return stats.Silverman(data), nil
+	case "sj":
+		return stats.SheatherJones(data), nil
+	}
+	return 0, errors.New("unknown bandwidth rule " + h.bw)
+}
+
+// Return an error if a rule or a kernel of the settings is unknown
+func (h Histo) check() error {
+	if h.bins != "" {
+		if _, err := h.binRule(); err != nil {
+			return err
+		}
+	}
+	if _, err := h.densityKernel(); err != nil {
+		return err
+	}
+	switch h.bw {
+	case "silverman", "sj":
+	default:
+		return errors.New("unknown bandwidth rule " + h.bw)
+	}
+	return nil
+}
+
+// Definition of a group of configs compared one each other
+type CompareGroup struct {
+	name    string   // unique name of the group
+	configs []string // names of the compared configs
+}
+
 // Create a string legend from the config fields
 func (c Config) legend() string {
 	// return fmt.Sprintf("size = %0.2f Mb", c.mb) // return the size
@@ -34,7 +106,8 @@ func (c Config) legend() string {
 }
 
 // Prepare the config object before using it in the draw functions
-func (c *Config) prepare() {
+// return an error if its histogram settings are unknown
+func (c *Config) prepare() error {
 	// Replace sufix with the real path (root + prefix + sufix + postfix) for each sufix
 	sfx := make([]string, len(c.sufix))
 	for i := range sfx {
@@ -49,6 +122,21 @@ func (c *Config) prepare() {
 	if c.kb == 0 {
 		c.kb = 100
 	}
+	// histogram settings not set take the options
+	if c.histo.bins == "" {
+		c.histo.bins = BINS
+	}
+	c.histo.logbins = c.histo.logbins || LOGBINS
+	if c.histo.kernel == "" {
+		c.histo.kernel = KERNEL
+	}
+	if c.histo.bw == "" {
+		c.histo.bw = BW
+	}
+	if err := c.histo.check(); err != nil {
+		return fmt.Errorf("config %s : %v", c.name, err)
+	}
+	return nil
 }
 
 // Return the index of the config that has the same name, or -1 if not found
@@ -61,6 +149,16 @@ func findConfigIdx(name string) int {
 	return -1
 }
 
+// Return the index of the comparison group that has the same name, or -1 if not found
+func findGroupIdx(name string) int {
+	for i, g := range CompareGroups {
+		if name == g.name {
+			return i
+		}
+	}
+	return -1
+}
+
 // Transform the slice of strings into the slice of corresponding configs
 func toConfigs(cs []string) ([]Config, error) {
 	cfg := make([]Config, len(cs))
@@ -74,170 +172,261 @@ func toConfigs(cs []string) ([]Config, error) {
 	return cfg, nil
 }
 
-// Add the diagram types here
+// Add the diagram types here (and their name in drawInfos)
 type Draws int
 
 const (
-	Dall           Draws = iota // Draw all diagram types (except Dcompare)
-	Dfile                       // Draw the raw points
-	DhistoFile                  // Draw the equivalent histogram
-	DmeansFile                  // Draw the computed mean
-	DmeansErrFiles              // Draw the computed mean with error deviations
-	DslideFile                  // Draw a sliding window accross the points
-	Dthroughput                 // Draw the throughput
-	DnbMsgPerSec                // Draw the number of messages per second
+	Dall            Draws = iota // Draw all diagram types (except Dcompare)
+	Dfile                        // Draw the raw points
+	DhistoFile                   // Draw the equivalent histogram
+	DmeansFile                   // Draw the computed mean
+	DmeansErrFiles               // Draw the computed mean with error deviations
+	DslideFile                   // Draw a sliding window accross the points
+	Dthroughput                  // Draw the throughput
+	DnbMsgPerSec                 // Draw the number of messages per second
+	DthroughputTime              // Draw the throughput over time within each file
+	DheatmapFile                 // Draw the latency density over time as a heat map
+	DboxFiles                    // Draw the latency distribution of each file as box plots
+	DviolinFiles                 // Draw the latency distribution of each file as violin plots
+	DcdfFile                     // Draw the empirical cumulative and complementary cumulative distributions
+	DqqFile                      // Draw the quantile-quantile plots against fitted distributions
+	DacfFile                     // Draw the autocorrelation function of the latencies
+	DspectrumFile                // Draw the periodogram of the latencies
+	DslideQuant                  // Draw the median and percentiles of a sliding window accross the points
+	DpercentileFile              // Draw the latency percentile distribution, raw and corrected for the coordinated omission
 )
 
 var draws = []Draws{
-	Dall, Dfile, DhistoFile, DmeansFile, DmeansErrFiles, DslideFile, Dthroughput, DnbMsgPerSec,
+	Dall, Dfile, DhistoFile, DmeansFile, DmeansErrFiles, DslideFile, Dthroughput, DnbMsgPerSec, DthroughputTime,
+	DheatmapFile, DboxFiles, DviolinFiles, DcdfFile, DqqFile, DacfFile, DspectrumFile, DslideQuant, DpercentileFile,
+}
+
+// Name (option -draw), description and scope of the diagram types
+var drawInfos = [...]struct {
+	name  string
+	help  string
+	scope string // "file" : one diagram per data file (option -file), "config" : one diagram per config
+}{
+	Dall:            {"all", "Draw all diagram types", ""},
+	Dfile:           {"raw", "Draw file raw data", "file"},
+	DhistoFile:      {"hist", "Draw histograms", "file"},
+	DmeansFile:      {"means", "Draw means", "config"},
+	DmeansErrFiles:  {"errs", "Draw means with errors", "config"},
+	DslideFile:      {"slide", "Draw a sliding window", "file"},
+	Dthroughput:     {"throughput", "Draw throughput", "config"},
+	DnbMsgPerSec:    {"msgps", "Draw the number of messages per seconds", "config"},
+	DthroughputTime: {"throughput-time", "Draw the throughput over time", "file"},
+	DheatmapFile:    {"heatmap", "Draw the latency heat map", "file"},
+	DboxFiles:       {"box", "Draw box plots", "config"},
+	DviolinFiles:    {"violin", "Draw violin plots", "config"},
+	DcdfFile:        {"cdf", "Draw the cumulative distributions", "file"},
+	DqqFile:         {"qq", "Draw the quantile-quantile plots", "file"},
+	DacfFile:        {"acf", "Draw the autocorrelation functions", "file"},
+	DspectrumFile:   {"spectrum", "Draw the periodograms", "file"},
+	DslideQuant:     {"slide-quant", "Draw the sliding window percentiles", "file"},
+	DpercentileFile: {"percentile", "Draw the percentile distributions", "file"},
 }
 
 func (d Draws) String() string {
-	return [...]string{"Draw all", "Draw file raw data", "Draw histograms", "Draw means",
-		"Draw means with errors", "Draw a sliding window", "Draw throughput", "Draw the number of messages per seconds"}[d]
+	return drawInfos[d].help
+}
+
+// Name of the diagram type in the option -draw
+func (d Draws) Name() string {
+	return drawInfos[d].name
 }
 
 // Describe the different draws in the help (-h)
 func helpDraw() string {
 	var s string
-	for i, d := range draws {
-		s = fmt.Sprintf("%s\n%d = %s", s, i, d)
+	for _, d := range draws {
+		scope := ""
+		if drawInfos[d].scope != "" {
+			scope = " (one per " + drawInfos[d].scope + ")"
+		}
+		s = fmt.Sprintf("%s\n  %-15s %s%s", s, d.Name(), d, scope)
 	}
 	return s
 }
 
-// Main entry point.
-// go run main.go drawings.go inputs.go -C
-func main() {
-	d := flag.Int("d", 0, "Drawing type (default 0)"+helpDraw())
-	n := flag.Int("n", -1, "File number to process as example or -1 for all")
-	l := flag.Int("l", NVAL, "Window interval when using the drawSlide")
-	o := flag.Int("o", NCOL, "Number of columns of the histograms")
-	p := flag.Bool("p", PRINT, "Print the moments of the distribution while drawing")
-	c := flag.String("c", "msgSizeAck1", "Name of the config to process")
-	compar := flag.Bool("C", false, "Run in comparison mode")
-	flag.Parse()
-
-	checkOptions(*d, *n, *l, *o, *c, *p)
-
-	switch {
-	case *compar:
-		{
-			compareAll()
+// Parse a comma separated list of diagram types, given by name or by number
+// "all" replaces the other types
+func parseDraws(list string) ([]Draws, error) {
+	var ds []Draws
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		found := false
+		for _, d := range draws {
+			if name == d.Name() || name == strconv.Itoa(int(d)) {
+				if d == Dall {
+					return []Draws{Dall}, nil
+				}
+				if !drawsContain(ds, d) {
+					ds = append(ds, d)
+				}
+				found = true
+			}
 		}
-	case *c == "all":
-		{
-			processAllConfigs(draws[*d], *n)
+		if !found {
+			return nil, errors.New("Unknown diagram type " + name + ", see the help (-h)")
 		}
-	default:
-		{
-			idx := findConfigIdx(*c)
-			if idx == -1 {
-				fmt.Println("No config found with name : ", *c)
-				return
-			}
-			drawConfig(Configs[idx], draws[*d], *n)
+	}
+	return ds, nil
+}
+
+// Return true if the diagram type is in the slice
+func drawsContain(ds []Draws, d Draws) bool {
+	for _, v := range ds {
+		if v == d {
+			return true
 		}
 	}
+	return false
 }
 
-// Check the program arguments (options) and exit in case of error
-func checkOptions(d, n, l, o int, c string, p bool) {
-	if d < 0 || d >= len(draws) {
-		fmt.Println("Error : bad drawing type. Should be in [ 0, ", len(draws)-1, "]")
-		os.Exit(1)
+// A subcommand of the program
+type command struct {
+	name string
+	help string
+	run  func(ctx context.Context, args []string) error
+}
+
+// The subcommands, in the order of the help
+var commands []command
+
+func init() {
+	commands = []command{
+		{"draw", "Draw the diagrams of a config or of all configs", cmdDraw},
+		{"compare", "Compare the configs of the comparison groups one each other", cmdCompare},
+		{"stats", "Print the stats of each file of a config, save them as a baseline or store them in a database", cmdStats},
+		{"check", "Check the stats of a config against a baseline, fail on regression", cmdCheck},
+		{"trend", "Draw the evolution across the stored runs of the stats of a config at an abscissa", cmdTrend},
+		{"list", "List the configs, the comparison groups and the diagram types", cmdList},
+		{"report", "Draw all diagrams of a config in a folder with an HTML page of the diagrams and stats", cmdReport},
+		{"validate", "Check that the data files of the configs exist and can be parsed", cmdValidate},
+		{"serve", "Serve an HTTP dashboard rendering the diagrams of the configs and comparisons on request", cmdServe},
+		{"watch", "Watch the folders of the configs and redraw the diagrams of the files added or modified", cmdWatch},
 	}
-	if n < -1 {
-		fmt.Println("Error : the file number should be positive. Found", n)
-		os.Exit(1)
+}
+
+// Print the list of the subcommands
+func usage() {
+	fmt.Println("Usage: plots <command> [options]\n\nCommands:")
+	for _, c := range commands {
+		fmt.Printf("  %-9s %s\n", c.name, c.help)
 	}
-	if l < 2 {
-		fmt.Println("Error : window interval should be greater that 1. Found", l)
-		os.Exit(1)
+	fmt.Println("\nRun plots <command> -h for the options of a command")
+}
+
+// Exit code of a command : 0 on success, 130 if the failed command was interrupted, else 1
+func exitCode(ctx context.Context, err error) int {
+	switch {
+	case err == nil:
+		return 0
+	case ctx.Err() != nil || errors.Is(err, context.Canceled):
+		return 130
 	}
-	if o < 2 {
-		fmt.Println("Error : the histogram number of columns should be greater that 1. Found", l)
-		os.Exit(1)
+	return 1
+}
+
+// Main entry point.
+// go run . draw -config msgSizeAck1 -draw hist,throughput
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
 	}
-	PRINT, NVAL, NCOL = p, l, o
+	name, args := os.Args[1], os.Args[2:]
+	if name == "help" || name == "-h" || name == "-help" {
+		usage()
+		return
+	}
+	// the first interruption stops the drawings cleanly, the second one exits
+	ctx, cancel := context.WithCancel(context.Background())
+	sig := make(chan os.Signal, 2)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sig
+		fmt.Println("Interrupted, finishing the running diagrams (interrupt again to exit now)")
+		cancel()
+		<-sig
+		os.Exit(130)
+	}()
+	for _, c := range commands {
+		if c.name == name {
+			if err := c.run(ctx, args); err != nil {
+				switch err.(type) {
+				case Errors, *DrawError:
+					printErrors(err)
+				default:
+					fmt.Println("Error :", err)
+				}
+				os.Exit(exitCode(ctx, err))
+			}
+			return
+		}
+	}
+	fmt.Println("Unknown command", name)
+	usage()
+	os.Exit(2)
 }
 
 // Compare the configs defined by their name in the given slice one each other
-func compareConfigs(names []string) error {
+// "name" is the name of the comparison in the errors
+func compareConfigs(ctx context.Context, name string, names []string, n int) error {
 	confs, err := toConfigs(names)
 	if err != nil {
 		return err
 	}
-	return doCompare(confs)
+	return doCompare(ctx, name, confs, n)
 }
 
 // Run all comparisons in parallel
-func compareAll() {
+// "n" is the number of the abscissa for the per abscissa comparisons (-1 = all abscissa)
+func compareAll(ctx context.Context, n int) error {
 	ComparePNGsuffix = "per_partition" // sufix added to PNG names
 	plotfunc.N = 10
-	var wg sync.WaitGroup
-	wg.Add(8)
-	go func() {
-		if err := compareConfigs([]string{"p6_queueBufMaxMs_100k", "p36_queueBufMaxMs_100k", "p72_queueBufMaxMs_100k", "p108_queueBufMaxMs_100k", "p180_queueBufMaxMs_100k", "p360_queueBufMaxMs_100k"}); err != nil {
-			fmt.Println(err)
-		}
-		wg.Done()
-	}()
-	go func() {
-		if err := compareConfigs([]string{"p6_queuedMinMessages_100k", "p36_queuedMinMessages_100k", "p72_queuedMinMessages_100k", "p108_queuedMinMessages_100k", "p180_queuedMinMessages_100k", "p360_queuedMinMessages_100k"}); err != nil {
-			fmt.Println(err)
-		}
-		wg.Done()
-	}()
-	go func() {
-		if err := compareConfigs([]string{"p6_queueBufMaxMsg_100k", "p36_queueBufMaxMsg_100k", "p72_queueBufMaxMsg_100k", "p108_queueBufMaxMsg_100k", "p180_queueBufMaxMsg_100k", "p360_queueBufMaxMsg_100k"}); err != nil {
-			fmt.Println(err)
-		}
-		wg.Done()
-	}()
-	go func() {
-		if err := compareConfigs([]string{"p6_batchNumMsg_100k", "p36_batchNumMsg_100k", "p72_batchNumMsg_100k", "p108_batchNumMsg_100k", "p180_batchNumMsg_100k", "p360_batchNumMsg_100k"}); err != nil {
-			fmt.Println(err)
-		}
-		wg.Done()
-	}()
-	go func() {
-		if err := compareConfigs([]string{"p6_fetchMinBytes_100k", "p36_fetchMinBytes_100k", "p72_fetchMinBytes_100k", "p108_fetchMinBytes_100k", "p180_fetchMinBytes_100k", "p360_fetchMinBytes_100k"}); err != nil {
-			fmt.Println(err)
+	var tasks []task
+	for _, g := range CompareGroups {
+		confs, err := toConfigs(g.configs)
+		if err != nil {
+			return err
 		}
-		wg.Done()
-	}()
-	go func() {
-		if err := compareConfigs([]string{"p6_fetchWaitMaxMs_100k", "p36_fetchWaitMaxMs_100k", "p72_fetchWaitMaxMs_100k", "p108_fetchWaitMaxMs_100k", "p180_fetchWaitMaxMs_100k", "p360_fetchWaitMaxMs_100k"}); err != nil {
-			fmt.Println(err)
+		ts, err := compareTasks(g.name, confs, n)
+		if err != nil {
+			return err
 		}
-		wg.Done()
-	}()
-	go func() {
-		if err := compareConfigs([]string{"p6_queueBufMaxKbytes_100k", "p36_queueBufMaxKbytes_100k", "p72_queueBufMaxKbytes_100k", "p108_queueBufMaxKbytes_100k", "p180_queueBufMaxKbytes_100k", "p360_queueBufMaxKbytes_100k"}); err != nil {
-			fmt.Println(err)
-		}
-		wg.Done()
-	}()
-	go func() {
-		if err := compareConfigs([]string{"p6_msgSize", "p36_msgSize", "p72_msgSize", "p108_msgSize", "p180_msgSize", "p360_msgSize"}); err != nil {
-			fmt.Println(err)
+		tasks = append(tasks, ts...)
+	}
+	return runTasks(ctx, tasks)
+}
+
+// Draw the diagram types "ds" of the config, without KEEPGOING stop at the first failure
+func drawConfigs(ctx context.Context, c Config, ds []Draws, n int) error {
+	var tasks []task
+	for _, d := range ds {
+		ts, err := configTasks(c, d, n)
+		if err != nil {
+			return err
 		}
-		wg.Done()
-	}()
-	wg.Wait()
+		tasks = append(tasks, ts...)
+	}
+	return runTasks(ctx, tasks)
 }
 
-// Process all configs according to the parameters in parallel
-func processAllConfigs(draw Draws, fileNb int) {
+// Process all configs according to the parameters in parallel, JOBS diagrams at a time
+// without KEEPGOING the diagrams not started are skipped after the first failure
+func processAllConfigs(ctx context.Context, ds []Draws, fileNb int) error {
 	plotfunc.N = 1
-	var wg sync.WaitGroup
-	for _, cfg := range Configs {
-		wg.Add(1)
-		go func(c Config) {
-			drawConfig(c, draw, fileNb)
-			wg.Done()
-		}(cfg)
-	}
-	wg.Wait()
+	var tasks []task
+	for _, c := range Configs {
+		for _, d := range ds {
+			ts, err := configTasks(c, d, fileNb)
+			if err != nil {
+				return err
+			}
+			tasks = append(tasks, ts...)
+		}
+	}
+	return runTasks(ctx, tasks)
 }
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// The diagram types are given by name or number, once each, and all replaces the others
+func TestParseDraws(t *testing.T) {
+	for _, c := range []struct {
+		list   string
+		wanted []Draws
+		fails  bool
+	}{
+		{"hist", []Draws{DhistoFile}, false},
+		{"2", []Draws{DhistoFile}, false},
+		{"hist, box,2", []Draws{DhistoFile, DboxFiles}, false},
+		{"raw,all,box", []Draws{Dall}, false},
+		{"0", []Draws{Dall}, false},
+		{"hist,foo", nil, true},
+		{"", nil, true},
+	} {
+		ds, err := parseDraws(c.list)
+		if (err != nil) != c.fails || !reflect.DeepEqual(ds, c.wanted) {
+			t.Errorf("Bad parse of %q: wanted: %v (error %t) found: %v %v", c.list, c.wanted, c.fails, ds, err)
+		}
+	}
+}
+
+// Exit codes of the successful, failed and interrupted commands
+func TestExitCode(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	failure := errors.New("failure")
+	for _, c := range []struct {
+		name string
+		ctx  context.Context
+		err  error
+		code int
+	}{
+		{"success", context.Background(), nil, 0},
+		{"interrupted success", cancelled, nil, 0},
+		{"failure", context.Background(), failure, 1},
+		{"failures", context.Background(), Errors{failure, failure}, 1},
+		{"interrupted", cancelled, Errors{failure, context.Canceled}, 130},
+		{"interrupted drawing", context.Background(), &DrawError{Config: "c", Diagram: "hist", Err: context.Canceled}, 130},
+	} {
+		if code := exitCode(c.ctx, c.err); code != c.code {
+			t.Errorf("Bad exit code of %s: wanted: %d found: %d", c.name, c.code, code)
+		}
+	}
+}
+
+// The unknown histogram settings of a config are refused by prepare, the settings not set take the options
+func TestPrepareHisto(t *testing.T) {
+	for _, c := range []struct {
+		h     Histo
+		fails bool
+	}{
+		{Histo{}, false},
+		{Histo{bins: "scott", kernel: "epanechnikov", bw: "sj"}, false},
+		{Histo{bins: "scot"}, true},
+		{Histo{kernel: "gaus"}, true},
+		{Histo{bw: "silvermann"}, true},
+	} {
+		conf := Config{name: "c", histo: c.h}
+		if err := conf.prepare(); (err != nil) != c.fails {
+			t.Errorf("Bad check of %+v: wanted error %t found: %v", c.h, c.fails, err)
+		}
+		if c.h.bw == "" && conf.histo.bw != BW {
+			t.Errorf("Bad default bandwidth rule: wanted: %s found: %s", BW, conf.histo.bw)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Maximum number of diagrams drawn in parallel (option -j)
+var JOBS = runtime.NumCPU()
+
+// A drawing task : a diagram of a data file, or of all the files of a config or of a comparison group
+type task struct {
+	config  string // name of the config or of the comparison group
+	file    string // data file, empty if the diagram is drawn from all files
+	diagram string // name of the diagram type
+	f       func(context.Context) error
+}
+
+// Run the tasks in order with JOBS workers and return their failures
+// without KEEPGOING, the tasks not started are skipped after the first failure
+// if ctx is cancelled (interruption), the running tasks are finished and the completed ones are reported
+func runTasks(ctx context.Context, tasks []task) error {
+	// cancelled by an interruption or by the first failure
+	run, cancel := context.WithCancel(ctx)
+	defer cancel()
+	jobs := make(chan task)
+	var mu sync.Mutex
+	var errs Errors
+	done := make(map[string]int) // number of completed tasks per config
+	var wg sync.WaitGroup
+	for w := 0; w < JOBS; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range jobs {
+				err := drawProtected(t.config, t.file, t.diagram, func() error { return t.f(run) })
+				mu.Lock()
+				switch {
+				case err == nil:
+					done[t.config]++
+				case errors.Is(err, context.Canceled):
+					// interrupted while running, neither completed nor failed
+				case errs.add(err):
+					cancel()
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+schedule:
+	for _, t := range tasks {
+		select {
+		case jobs <- t:
+		case <-run.Done():
+			break schedule
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	if ctx.Err() != nil {
+		printInterrupted(tasks, done, len(errs))
+		errs = append(errs, ctx.Err())
+	}
+	return errs.err()
+}
+
+// Print the tasks completed before the interruption
+func printInterrupted(tasks []task, done map[string]int, failed int) {
+	total := make(map[string]int)
+	var names []string
+	for _, t := range tasks {
+		if total[t.config] == 0 {
+			names = append(names, t.config)
+		}
+		total[t.config]++
+	}
+	sort.Strings(names)
+	nb := 0
+	var complete, partial []string
+	for _, name := range names {
+		nb += done[name]
+		switch {
+		case done[name] == total[name]:
+			complete = append(complete, name)
+		case done[name] > 0:
+			partial = append(partial, fmt.Sprintf("%s (%d/%d)", name, done[name], total[name]))
+		}
+	}
+	fmt.Printf("Interrupted : %d of %d diagrams drawn, %d failed, %d not drawn\n", nb, len(tasks), failed, len(tasks)-nb-failed)
+	if len(complete) > 0 {
+		fmt.Println("Completed :", strings.Join(complete, ", "))
+	}
+	if len(partial) > 0 {
+		fmt.Println("Partially drawn :", strings.Join(partial, ", "))
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Return the standard output of f
+func captureOutput(f func(), t *testing.T) string {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+	f()
+	w.Close()
+	out, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+// Tasks of the config c : the "fail" ones fail, the others are counted in drawn
+// a task started after a cancellation returns it, as the drawings do
+func testTasks(kinds []string, drawn *int, mu *sync.Mutex) []task {
+	var tasks []task
+	for _, k := range kinds {
+		k := k
+		tasks = append(tasks, task{"c", "", k, func(ctx context.Context) error {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if k == "fail" {
+				return errors.New("failed")
+			}
+			mu.Lock()
+			*drawn++
+			mu.Unlock()
+			return nil
+		}})
+	}
+	return tasks
+}
+
+// Without KEEPGOING the tasks following the first failure are not drawn, else all are
+func TestRunTasks(t *testing.T) {
+	defer func(k bool, j int) { KEEPGOING, JOBS = k, j }(KEEPGOING, JOBS)
+	for _, c := range []struct {
+		keepGoing bool
+		jobs      int
+		kinds     []string
+		drawn     int
+		failed    int
+	}{
+		{false, 1, []string{"ok", "ok"}, 2, 0},
+		{false, 1, []string{"ok", "fail", "ok", "fail"}, 1, 1},
+		{true, 1, []string{"ok", "fail", "ok", "fail"}, 2, 2},
+		{true, 4, []string{"fail", "ok", "ok", "fail", "ok"}, 3, 2},
+	} {
+		KEEPGOING, JOBS = c.keepGoing, c.jobs
+		var mu sync.Mutex
+		drawn := 0
+		err := runTasks(context.Background(), testTasks(c.kinds, &drawn, &mu))
+		es, _ := err.(Errors)
+		if drawn != c.drawn || len(es) != c.failed || (c.failed == 0) != (err == nil) {
+			t.Errorf("Bad run of %v with keep-going=%t: wanted: %d drawn %d failed found: %d drawn %v", c.kinds, c.keepGoing, c.drawn, c.failed, drawn, err)
+		}
+		for _, e := range es {
+			if de, ok := e.(*DrawError); !ok || de.Config != "c" || de.Diagram != "fail" {
+				t.Errorf("Bad failure: %#v", e)
+			}
+		}
+	}
+}
+
+// An interruption stops the scheduling, the failures are kept and the interruption is reported last
+func TestRunTasksCancel(t *testing.T) {
+	defer func(k bool, j int) { KEEPGOING, JOBS = k, j }(KEEPGOING, JOBS)
+	KEEPGOING, JOBS = true, 1
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var mu sync.Mutex
+	drawn := 0
+	tasks := testTasks([]string{"fail", "ok", "interrupt", "ok", "ok"}, &drawn, &mu)
+	tasks[2].f = func(context.Context) error {
+		cancel()
+		return nil
+	}
+	var err error
+	out := captureOutput(func() { err = runTasks(ctx, tasks) }, t)
+	es, _ := err.(Errors)
+	if drawn != 1 || len(es) != 2 || !errors.Is(es[1], context.Canceled) || errors.Is(es[0], context.Canceled) {
+		t.Errorf("Bad interrupted run: %d drawn, %v", drawn, err)
+	}
+	if !strings.Contains(out, "Interrupted : 2 of 5 diagrams drawn, 1 failed, 2 not drawn") {
+		t.Errorf("Bad report of the interruption: %q", out)
+	}
+	if code := exitCode(ctx, err); code != 130 {
+		t.Errorf("Bad exit code of an interrupted run: %d", code)
+	}
+}
+
+// The configs are reported completed or partially drawn, the ones without any diagram drawn are not listed
+func TestPrintInterrupted(t *testing.T) {
+	tasks := []task{{config: "b"}, {config: "a"}, {config: "b"}, {config: "c"}, {config: "a"}}
+	out := captureOutput(func() { printInterrupted(tasks, map[string]int{"a": 2, "b": 1}, 1) }, t)
+	wanted := "Interrupted : 3 of 5 diagrams drawn, 1 failed, 1 not drawn\nCompleted : a\nPartially drawn : b (1/2)\n"
+	if out != wanted {
+		t.Errorf("Bad report: wanted: %q found: %q", wanted, out)
+	}
+	out = captureOutput(func() { printInterrupted(tasks, map[string]int{}, 0) }, t)
+	if out != "Interrupted : 0 of 5 diagrams drawn, 0 failed, 5 not drawn\n" {
+		t.Errorf("Bad report without any diagram drawn: %q", out)
+	}
+}
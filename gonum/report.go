@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const reportHTML = `<!DOCTYPE html>
+<html><head><title>{{.Name}}</title>
+<style>table { border-collapse: collapse } td, th { border: 1px solid #ccc; padding: 2px 6px; text-align: right }</style>
+</head><body>
+<h1>{{.Name}}</h1>
+<p>{{.Title}} {{.Root}}</p>
+<p>plots {{.Args}}</p>
+<h2>Stats</h2>
+<table>
+<tr><th>{{.Xlabel}}</th><th>n</th><th>mean (ms)</th><th>sdev (ms)</th><th>median (ms)</th><th>p95 (ms)</th><th>p99 (ms)</th><th>p999 (ms)</th><th>throughput (Mb/s)</th><th>msg / s</th></tr>
+{{range .Stats.Files}}<tr><td>{{.Abscissa}}</td><td>{{.N}}</td><td>{{printf "%.4g" .Mean}}</td><td>{{printf "%.4g" .Sdev}}</td><td>{{printf "%.4g" .Median}}</td><td>{{printf "%.4g" .P95}}</td><td>{{printf "%.4g" .P99}}</td><td>{{printf "%.4g" .P999}}</td><td>{{printf "%.4g" .Throughput}}</td><td>{{printf "%.4g" .MsgPerSec}}</td></tr>
+{{end}}</table>
+<h2>Diagrams</h2>
+{{range .Images}}<figure><img src="{{.}}" alt="{{.}}"><figcaption>{{.}}</figcaption></figure>
+{{end}}
+</body></html>
+`
+
+var reportTmpl = template.Must(template.New("report").Parse(reportHTML))
+
+// plots report : draw the diagrams of a config in a folder with an HTML page of the diagrams and stats
+func cmdReport(ctx context.Context, args []string) error {
+	fs := newFlags("report", "")
+	checkAnalysis := analysisFlags(fs)
+	checkOutput := outputFlags(fs)
+	c := fs.String("config", "msgSizeAck1", "Name of the config")
+	list := fs.String("draw", Dall.Name(), "Comma separated diagram types:"+helpDraw())
+	n := fs.Int("file", -1, "File number to process as example or -1 for all")
+	if err := parseFlags(fs, args, checkAnalysis, checkOutput); err != nil {
+		return err
+	}
+	ds, err := parseDraws(*list)
+	if err != nil {
+		return err
+	}
+	cfg, err := findConfig(*c)
+	if err != nil {
+		return err
+	}
+	if *n < -1 || *n >= len(cfg.sufix) {
+		return fmt.Errorf("the file number should be in [-1, %d]. Found %d", len(cfg.sufix)-1, *n)
+	}
+	// each file is read by several diagrams
+	CACHE = true
+	if OUTDIR == "" {
+		OUTDIR = "report_" + cfg.name
+	}
+	if err = os.MkdirAll(OUTDIR, 0755); err != nil {
+		return err
+	}
+	if err = drawConfigs(ctx, cfg, ds, *n); err != nil {
+		if !KEEPGOING {
+			return err
+		}
+		printErrors(err)
+	}
+	return writeReport(ctx, cfg, strings.Join(append([]string{"report"}, args...), " "))
+}
+
+// Write the index.html page of the report in OUTDIR with the stats of the config and the images of the folder
+func writeReport(ctx context.Context, c Config, args string) error {
+	if err := c.prepare(); err != nil {
+		return err
+	}
+	b, err := computeBaseline(ctx, c)
+	if err != nil {
+		return err
+	}
+	infos, err := ioutil.ReadDir(OUTDIR)
+	if err != nil {
+		return err
+	}
+	var images []string
+	for _, info := range infos {
+		if filepath.Ext(info.Name()) == "."+FORMAT {
+			images = append(images, info.Name())
+		}
+	}
+	if len(images) == 0 {
+		return errors.New("No diagram found in " + OUTDIR)
+	}
+	path := filepath.Join(OUTDIR, "index.html")
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	data := struct {
+		Name, Title, Root, Xlabel string
+		Args                      string
+		Stats                     Baseline
+		Images                    []string
+	}{c.name, c.title, c.root, c.xlabel, args, b, images}
+	if err = reportTmpl.Execute(f, data); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	fmt.Println("Report written in", path)
+	return nil
+}
@@ -0,0 +1,498 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"plots/plotfunc"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Serialize the renderings : the drawings are configured by global variables
+// a long rendering (e.g. d=all) delays the other requests
+var serveMu sync.Mutex
+
+// Maximum number of renderings kept to serve their images on /image
+const maxRenderings = 16
+
+// State of a data file when rendering
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// A rendering kept in its folder, valid while its data files are not modified
+type rendering struct {
+	key, dir, format string
+	errs             []string
+	stamps           []fileStamp
+}
+
+var (
+	renderMu   sync.Mutex
+	renderings []*rendering // the most recent last
+)
+
+// An image of a rendered page
+type servedImage struct {
+	Name string
+	URI  template.URL // data URI of the image
+	Raw  string       // URL of the raw image
+}
+
+// A rendered page
+type servedPage struct {
+	Title  string
+	Err    string
+	Images []servedImage
+}
+
+// A config in the index page
+type servedConfig struct {
+	Name   string
+	Abscis []string
+}
+
+const indexHTML = `<!DOCTYPE html>
+<html><head><title>plots</title></head><body>
+<h1>Configs</h1>
+<form action="/draw">
+<p>Config <select name="config">{{range .Configs}}<option>{{.Name}}</option>{{end}}</select>
+Drawing <select name="d">{{range .Draws}}<option value="{{.Name}}">{{.}}</option>{{end}}</select>
+File number <input name="n" value="0" size="3"> (-1 = all)</p>
+<p>Points discarded <input name="discard" size="5"> (default of the config)
+Window <input name="l" value="{{.NVAL}}" size="5">
+Histogram columns <input name="o" value="{{.NCOL}}" size="5">
+Format <select name="format"><option>png</option><option>svg</option></select>
+<input type="submit" value="Draw"></p>
+</form>
+<ul>{{range $c := .Configs}}<li><a href="/draw?config={{$c.Name}}&amp;d=all&amp;n=-1">{{$c.Name}}</a> : {{range $i, $a := $c.Abscis}}<a href="/draw?config={{$c.Name}}&amp;d=all&amp;n={{$i}}">{{$a}}</a> {{end}}</li>
+{{end}}</ul>
+<h1>Comparisons</h1>
+<ul>{{range .Groups}}<li><a href="/compare?group={{.}}&amp;n=-1">{{.}}</a></li>
+{{end}}</ul>
+</body></html>
+`
+
+const pageHTML = `<!DOCTYPE html>
+<html><head><title>{{.Title}}</title></head><body>
+<p><a href="/">index</a></p>
+<h1>{{.Title}}</h1>
+{{if .Err}}<pre>{{.Err}}</pre>{{end}}
+{{range .Images}}<figure><a href="{{.Raw}}"><img src="{{.URI}}" alt="{{.Name}}"></a><figcaption>{{.Name}}</figcaption></figure>
+{{end}}
+</body></html>
+`
+
+var (
+	indexTmpl = template.Must(template.New("index").Parse(indexHTML))
+	pageTmpl  = template.Must(template.New("page").Parse(pageHTML))
+)
+
+// Start the HTTP dashboard on the given address (e.g. ":8080") until ctx is cancelled
+// the images are rendered on request from the data files, which are parsed once and cached
+func serve(ctx context.Context, addr string) error {
+	CACHE = true
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", serveIndex)
+	mux.HandleFunc("/draw", serveDraw)
+	mux.HandleFunc("/compare", serveCompare)
+	mux.HandleFunc("/image", serveImage)
+	srv := &http.Server{Addr: addr, Handler: mux}
+	go func() {
+		<-ctx.Done()
+		srv.Shutdown(context.Background())
+	}()
+	defer removeRenderings()
+	fmt.Println("Serving the plots on", addr)
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// List the configs and the comparison groups
+func serveIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	data := struct {
+		Configs    []servedConfig
+		Groups     []string
+		Draws      []Draws
+		NVAL, NCOL int
+	}{Draws: draws, NVAL: NVAL, NCOL: NCOL}
+	for _, c := range Configs {
+		c.prepare()
+		data.Configs = append(data.Configs, servedConfig{c.name, c.abscis})
+	}
+	for _, g := range CompareGroups {
+		data.Groups = append(data.Groups, g.name)
+	}
+	var buf strings.Builder
+	if err := indexTmpl.Execute(&buf, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprint(w, buf.String())
+}
+
+// Read an integer query parameter, def if missing
+func queryInt(r *http.Request, key string, def int) (int, error) {
+	s := r.URL.Query().Get(key)
+	if s == "" {
+		return def, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return def, fmt.Errorf("bad parameter %s: %s", key, s)
+	}
+	return v, nil
+}
+
+// Override the histogram settings with the parameters bins, logbins, kde and bw of the request, if present
+func queryHisto(r *http.Request, h *Histo) error {
+	q := r.URL.Query()
+	if s, found := q["bins"]; found {
+		h.bins = s[0]
+	}
+	if s := q.Get("logbins"); s != "" {
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("bad parameter logbins: %s", s)
+		}
+		h.logbins = v
+	}
+	if s, found := q["kde"]; found {
+		h.kernel = s[0]
+	}
+	if s := q.Get("bw"); s != "" {
+		h.bw = s
+	}
+	return h.check()
+}
+
+// Draw a config : parameters config, d (comma separated diagram types), n (file number, -1 = all), discard (number of points
+// to discard), l (window interval), o (histogram columns), bins, logbins, kde, bw (histogram settings) and format (png or svg)
+func serveDraw(w http.ResponseWriter, r *http.Request) {
+	title, draw, code, err := drawRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+	render(w, r, title, draw)
+}
+
+// Draw a comparison group : parameters group, n (abscissa number, -1 = all) and format (png or svg)
+func serveCompare(w http.ResponseWriter, r *http.Request) {
+	title, draw, code, err := compareRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+	render(w, r, title, draw)
+}
+
+// Send a single image of a config or of a comparison group : parameters of /draw or of /compare (if group is set)
+// and name, the name of the image
+func serveImage(w http.ResponseWriter, r *http.Request) {
+	request := drawRequest
+	if r.URL.Query().Get("group") != "" {
+		request = compareRequest
+	}
+	_, draw, code, err := request(r)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+	name := r.URL.Query().Get("name")
+	if name == "" || filepath.Base(name) != name {
+		http.Error(w, "bad image name "+name, http.StatusBadRequest)
+		return
+	}
+	// the images of a page are served from its rendering, rendered again if its data files were modified
+	key, stamps := renderKey(r), requestStamps(r)
+	rd := findRendering(key, stamps)
+	if rd == nil {
+		dir, format, errs, code, err := renderFiles(r, draw)
+		if err != nil {
+			http.Error(w, err.Error(), code)
+			return
+		}
+		rd = &rendering{key, dir, format, errs, stamps}
+		keepRendering(rd)
+	}
+	buf, format, errs, err := readRendered(rd, name)
+	if err != nil {
+		http.Error(w, "image "+name+" not drawn\n"+strings.Join(errs, "\n"), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", mimeType(format))
+	w.Write(buf)
+}
+
+// Return the key of the rendering of a request : its parameters without the image name (the same for a page and its images)
+func renderKey(r *http.Request) string {
+	q := r.URL.Query()
+	q.Del("name")
+	if q.Get("format") == "" {
+		q.Set("format", "png")
+	}
+	return q.Encode()
+}
+
+// Return the state of the data files of the config, or of the configs of the comparison group, of the request
+func requestStamps(r *http.Request) []fileStamp {
+	names := []string{r.URL.Query().Get("config")}
+	if idx := findGroupIdx(r.URL.Query().Get("group")); idx != -1 {
+		names = CompareGroups[idx].configs
+	}
+	var stamps []fileStamp
+	for _, name := range names {
+		idx := findConfigIdx(name)
+		if idx == -1 {
+			continue
+		}
+		c := Configs[idx]
+		c.prepare()
+		for _, f := range c.files {
+			var s fileStamp
+			if info, err := os.Stat(f); err == nil {
+				s = fileStamp{info.ModTime(), info.Size()}
+			}
+			stamps = append(stamps, s)
+		}
+	}
+	return stamps
+}
+
+// Return the kept rendering of the key if its data files are in the same state, nil if none
+func findRendering(key string, stamps []fileStamp) *rendering {
+	renderMu.Lock()
+	defer renderMu.Unlock()
+	for _, rd := range renderings {
+		if rd.key != key || len(rd.stamps) != len(stamps) {
+			continue
+		}
+		same := true
+		for i, s := range stamps {
+			same = same && s.modTime.Equal(rd.stamps[i].modTime) && s.size == rd.stamps[i].size
+		}
+		if same {
+			return rd
+		}
+	}
+	return nil
+}
+
+// Keep the rendering, replacing the previous one of the same key
+// the oldest renderings beyond maxRenderings are removed with their folder
+func keepRendering(rd *rendering) {
+	renderMu.Lock()
+	defer renderMu.Unlock()
+	kept := renderings[:0]
+	for _, old := range renderings {
+		if old.key == rd.key {
+			os.RemoveAll(old.dir)
+			continue
+		}
+		kept = append(kept, old)
+	}
+	renderings = append(kept, rd)
+	for len(renderings) > maxRenderings {
+		os.RemoveAll(renderings[0].dir)
+		renderings = renderings[1:]
+	}
+}
+
+// Remove the folders of the kept renderings
+func removeRenderings() {
+	renderMu.Lock()
+	defer renderMu.Unlock()
+	for _, rd := range renderings {
+		os.RemoveAll(rd.dir)
+	}
+	renderings = nil
+}
+
+// Read an image of the rendering, return it with the format and the errors of the rendering
+func readRendered(rd *rendering, name string) ([]byte, string, []string, error) {
+	renderMu.Lock()
+	defer renderMu.Unlock()
+	buf, err := ioutil.ReadFile(filepath.Join(rd.dir, name))
+	return buf, rd.format, rd.errs, err
+}
+
+// Read the parameters of a config drawing, return the title of the page and the drawing function
+// or the error and its HTTP status
+func drawRequest(r *http.Request) (string, func(context.Context) error, int, error) {
+	q := r.URL.Query()
+	idx := findConfigIdx(q.Get("config"))
+	if idx == -1 {
+		return "", nil, http.StatusNotFound, errors.New("No config found with name : " + q.Get("config"))
+	}
+	c := Configs[idx]
+	if err := c.prepare(); err != nil {
+		return "", nil, http.StatusInternalServerError, err
+	}
+	list := q.Get("d")
+	if list == "" {
+		list = Dall.Name()
+	}
+	ds, err := parseDraws(list)
+	n := 0
+	if err == nil {
+		if n, err = queryInt(r, "n", 0); err == nil && (n < -1 || n >= len(c.files)) {
+			err = fmt.Errorf("bad file number %d, should be in [-1, %d]", n, len(c.files)-1)
+		}
+	}
+	if err == nil {
+		if c.nbPtsDiscard, err = queryInt(r, "discard", c.nbPtsDiscard); err == nil && c.nbPtsDiscard < 0 {
+			err = fmt.Errorf("bad number of points to discard %d", c.nbPtsDiscard)
+		}
+	}
+	l, o := NVAL, NCOL
+	if err == nil {
+		if l, err = queryInt(r, "l", NVAL); err == nil && l < 2 {
+			err = fmt.Errorf("bad window interval %d, should be greater than 1", l)
+		}
+	}
+	if err == nil {
+		if o, err = queryInt(r, "o", NCOL); err == nil && o < 2 {
+			err = fmt.Errorf("bad number of columns %d, should be greater than 1", o)
+		}
+	}
+	if err == nil {
+		err = queryHisto(r, &c.histo)
+	}
+	if err != nil {
+		return "", nil, http.StatusBadRequest, err
+	}
+	title := fmt.Sprintf("%s : %s", c.name, list)
+	if n >= 0 {
+		title = fmt.Sprintf("%s (%s = %s)", title, c.xlabel, c.abscis[n])
+	}
+	return title, func(ctx context.Context) error {
+		NVAL, NCOL = l, o
+		plotfunc.N = 1
+		return drawConfigs(ctx, c, ds, n)
+	}, 0, nil
+}
+
+// Read the parameters of a comparison, return the title of the page and the drawing function
+// or the error and its HTTP status
+func compareRequest(r *http.Request) (string, func(context.Context) error, int, error) {
+	idx := findGroupIdx(r.URL.Query().Get("group"))
+	if idx == -1 {
+		return "", nil, http.StatusNotFound, errors.New("No comparison group found with name : " + r.URL.Query().Get("group"))
+	}
+	g := CompareGroups[idx]
+	n, err := queryInt(r, "n", -1)
+	if err == nil && n < -1 {
+		err = fmt.Errorf("bad abscissa number %d", n)
+	}
+	if err != nil {
+		return "", nil, http.StatusBadRequest, err
+	}
+	return "Comparison " + g.name, func(ctx context.Context) error {
+		ComparePNGsuffix = "per_partition"
+		plotfunc.N = 10
+		return compareConfigs(ctx, g.name, g.configs, n)
+	}, 0, nil
+}
+
+// Return the MIME type of the image format
+func mimeType(format string) string {
+	if format == "svg" {
+		return "image/svg+xml"
+	}
+	return "image/png"
+}
+
+// Run the drawing function in a temporary folder with the format of the request (png or svg)
+// Returns the folder (to be kept by keepRendering or removed by the caller), the format and the drawing errors
+// or the error and its HTTP status
+// the drawing stops if the client goes away
+func renderFiles(r *http.Request, draw func(context.Context) error) (string, string, []string, int, error) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "png"
+	}
+	if format != "png" && format != "svg" {
+		return "", "", nil, http.StatusBadRequest, errors.New("bad format " + format + ", should be png or svg")
+	}
+	dir, err := ioutil.TempDir("", "plots")
+	if err != nil {
+		return "", "", nil, http.StatusInternalServerError, err
+	}
+	var errs []string
+	serveMu.Lock()
+	outdir, fmtSave, nval, ncol, n, suffix := OUTDIR, FORMAT, NVAL, NCOL, plotfunc.N, ComparePNGsuffix
+	defer func() {
+		OUTDIR, FORMAT, NVAL, NCOL, plotfunc.N, ComparePNGsuffix = outdir, fmtSave, nval, ncol, n, suffix
+		serveMu.Unlock()
+	}()
+	OUTDIR, FORMAT = dir, format
+	if err := protect(func() error { return draw(r.Context()) }); err != nil {
+		es, ok := err.(Errors)
+		if !ok {
+			es = Errors{err}
+		}
+		for _, e := range es {
+			errs = append(errs, e.Error())
+		}
+	}
+	return dir, format, errs, 0, nil
+}
+
+// Run the drawing function and send the generated images in an HTML page, each image links to its raw version
+// the drawing errors are reported in the page with the status 500, with the images drawn before (or despite) them
+// the rendering is kept to serve the raw images
+func render(w http.ResponseWriter, r *http.Request, title string, draw func(context.Context) error) {
+	stamps := requestStamps(r)
+	dir, format, errs, code, err := renderFiles(r, draw)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+	defer keepRendering(&rendering{renderKey(r), dir, format, errs, stamps})
+
+	page := servedPage{Title: title}
+	for _, e := range errs {
+		page.Err += e + "\n"
+	}
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	for _, info := range infos {
+		buf, err := ioutil.ReadFile(filepath.Join(dir, info.Name()))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		uri := "data:" + mimeType(format) + ";base64," + base64.StdEncoding.EncodeToString(buf)
+		q := r.URL.Query()
+		q.Set("name", info.Name())
+		page.Images = append(page.Images, servedImage{info.Name(), template.URL(uri), "/image?" + q.Encode()})
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if page.Err != "" {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	if err = pageTmpl.Execute(w, page); err != nil {
+		fmt.Println("Error :", err)
+	}
+}
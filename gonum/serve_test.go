@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+// The renderings are found while their data files are unchanged, the oldest ones are removed with their folder
+func TestKeepRendering(t *testing.T) {
+	defer removeRenderings()
+	stamps := []fileStamp{{time.Unix(10, 0), 100}}
+	var dirs []string
+	for i := 0; i <= maxRenderings; i++ {
+		dir, err := ioutil.TempDir("", "plots")
+		if err != nil {
+			t.Fatal(err)
+		}
+		dirs = append(dirs, dir)
+		keepRendering(&rendering{key: fmt.Sprint(i), dir: dir, stamps: stamps})
+	}
+	if _, err := os.Stat(dirs[0]); !os.IsNotExist(err) || findRendering("0", stamps) != nil {
+		t.Errorf("Oldest rendering not removed: %v", err)
+	}
+	if rd := findRendering("1", stamps); rd == nil || rd.dir != dirs[1] {
+		t.Errorf("Rendering not found: %v", rd)
+	}
+	if rd := findRendering("1", []fileStamp{{time.Unix(11, 0), 100}}); rd != nil {
+		t.Error("Rendering found with a modified data file")
+	}
+	// a new rendering of the same key replaces the previous one
+	dir, err := ioutil.TempDir("", "plots")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keepRendering(&rendering{key: "1", dir: dir, stamps: stamps})
+	if _, err := os.Stat(dirs[1]); !os.IsNotExist(err) || findRendering("1", stamps).dir != dir {
+		t.Errorf("Rendering not replaced: %v", err)
+	}
+}
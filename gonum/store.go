@@ -0,0 +1,194 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"plots/plotfunc"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"gonum.org/v1/plot/vg"
+)
+
+// Path of the SQLite database storing the results of the runs (option -db), nothing stored if not set
+var DB = ""
+
+// Identifier of the run stored in the database (option -run), the current time if not set
+var RUN = ""
+
+// Version tags of the run stored in the database (options -git and -kafka)
+var GIT, KAFKA string
+
+const createResults = `CREATE TABLE IF NOT EXISTS results (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	run        TEXT    NOT NULL,
+	time       INTEGER NOT NULL,
+	config     TEXT    NOT NULL,
+	abscissa   TEXT    NOT NULL,
+	git        TEXT,
+	kafka      TEXT,
+	n          INTEGER,
+	ess        REAL,
+	mean       REAL,
+	sdev       REAL,
+	median     REAL,
+	p95        REAL,
+	p99        REAL,
+	p999       REAL,
+	throughput REAL,
+	msgPerSec  REAL,
+	UNIQUE (run, config, abscissa)
+)`
+
+// Open (and create if needed) the results database
+// the column ess is added to the databases created without it
+func openStore(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = db.Exec(createResults); err != nil {
+		db.Close()
+		return nil, err
+	}
+	var nb int
+	if err = db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('results') WHERE name = 'ess'`).Scan(&nb); err == nil && nb == 0 {
+		_, err = db.Exec(`ALTER TABLE results ADD COLUMN ess REAL`)
+	}
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// Store the stats of each file of a config (computed by computeBaseline) in the DB database
+// a run already stored with the same identifier is replaced
+func recordRun(b Baseline) error {
+	db, err := openStore(DB)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	now := time.Now()
+	run := RUN
+	if run == "" {
+		run = now.Format("20060102-150405")
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	for _, s := range b.Files {
+		_, err = tx.Exec(`INSERT OR REPLACE INTO results (run, time, config, abscissa, git, kafka,
+			n, ess, mean, sdev, median, p95, p99, p999, throughput, msgPerSec) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			run, now.Unix(), b.Config, s.Abscissa, GIT, KAFKA,
+			s.N, s.ESS, s.Mean, s.Sdev, s.Median, s.P95, s.P99, s.P999, s.Throughput, s.MsgPerSec)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	fmt.Printf("Run %s of %s stored in %s (%d files)\n", run, b.Config, DB, len(b.Files))
+	return nil
+}
+
+// A stored run of a config file
+type trendRow struct {
+	run, git, kafka string
+	time            time.Time
+	RunStats
+}
+
+// Read the runs of the config and abscissa stored in the DB database, by increasing time
+func loadTrend(config, abscissa string) ([]trendRow, error) {
+	db, err := openStore(DB)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	rows, err := db.Query(`SELECT run, time, IFNULL(git, ''), IFNULL(kafka, ''), n, IFNULL(ess, 0), mean, sdev, median, p95, p99, p999,
+		throughput, msgPerSec FROM results WHERE config = ? AND abscissa = ? ORDER BY time, id`, config, abscissa)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []trendRow
+	for rows.Next() {
+		var r trendRow
+		var t int64
+		if err = rows.Scan(&r.run, &t, &r.git, &r.kafka, &r.N, &r.ESS, &r.Mean, &r.Sdev, &r.Median, &r.P95, &r.P99, &r.P999,
+			&r.Throughput, &r.MsgPerSec); err != nil {
+			return nil, err
+		}
+		r.time = time.Unix(t, 0)
+		r.Abscissa = abscissa
+		res = append(res, r)
+	}
+	return res, rows.Err()
+}
+
+// Draw the evolution across the stored runs of the mean (with its standard error sdev / sqrt(ESS), the latencies
+// being autocorrelated) and p99 latencies,
+// and of the throughput of the config file at the given abscissa
+// image names = ${config}_${abscissa}_trend_latency.png and ${config}_${abscissa}_trend_throughput.png
+func drawTrend(c Config, abscissa string) error {
+	rows, err := loadTrend(c.name, abscissa)
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return errors.New("No run stored in " + DB + " for " + c.name + " at " + abscissa)
+	}
+	x := make([]float64, len(rows))
+	runs := make([]string, len(rows))
+	means := make([]float64, len(rows))
+	errs := make([]float64, len(rows))
+	p99s := make([]float64, len(rows))
+	nodevs := make([]float64, len(rows))
+	trput := make([]float64, len(rows))
+	for i, r := range rows {
+		x[i] = float64(i)
+		runs[i] = r.run
+		means[i] = r.Mean
+		errs[i] = r.Sdev / math.Sqrt(effectiveSize(r.RunStats))
+		p99s[i] = r.P99
+		trput[i] = r.Throughput
+		fmt.Printf("Run %-16s %s git=%s kafka=%s n=%d ess=%.0f mean=%.3e p99=%.3e throughput=%.3e\n",
+			r.run, r.time.Format(time.RFC3339), r.git, r.kafka, r.N, effectiveSize(r.RunStats), r.Mean, r.P99, r.Throughput)
+	}
+	title := fmt.Sprintf("%s\n(%s = %s)", c.name, c.xlabel, abscissa)
+	base := fmt.Sprintf("%s_%s_trend", c.name, abscissa)
+	// Latencies
+	p, err := plotfunc.NewPlot(title, "run", "times (ms)")
+	if err != nil {
+		return err
+	}
+	if err = plotfunc.AddWithErrXY(x, means, errs, "mean", 0, p); err != nil {
+		return err
+	}
+	if err = plotfunc.AddWithErrXY(x, p99s, nodevs, "p99", 1, p); err != nil {
+		return err
+	}
+	p.NominalX(runs...)
+	p.X.Min, p.X.Max = -0.5, float64(len(runs))-0.5
+	if err = savePlot(p, 15*vg.Centimeter, 10*vg.Centimeter, base+"_latency.png"); err != nil {
+		return err
+	}
+	// Throughput
+	p, err = plotfunc.NewPlot(title, "run", "throughput (Mb/s)")
+	if err != nil {
+		return err
+	}
+	if err = plotfunc.AddWithErrXY(x, trput, nodevs, "", 0, p); err != nil {
+		return err
+	}
+	p.NominalX(runs...)
+	p.X.Min, p.X.Max = -0.5, float64(len(runs))-0.5
+	return savePlot(p, 15*vg.Centimeter, 10*vg.Centimeter, base+"_throughput.png")
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"plots/sliceutil"
+)
+
+// plots validate : check that the data files of the configs exist and can be parsed,
+// and that the comparison groups refer to existing configs
+func cmdValidate(ctx context.Context, args []string) error {
+	fs := newFlags("validate", "")
+	c := fs.String("config", "all", "Name of the config to validate, or all (also validates the comparison groups)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	confs, err := selectConfigs(*c)
+	if err != nil {
+		return err
+	}
+	nb := 0
+	for _, cfg := range confs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		pbs := validateConfig(cfg)
+		for _, pb := range pbs {
+			fmt.Printf("%s : %s\n", cfg.name, pb)
+		}
+		nb += len(pbs)
+	}
+	if *c == "all" {
+		for _, g := range CompareGroups {
+			pbs := validateGroup(g)
+			for _, pb := range pbs {
+				fmt.Printf("comparison %s : %s\n", g.name, pb)
+			}
+			nb += len(pbs)
+		}
+	}
+	if nb > 0 {
+		return fmt.Errorf("%d problems found", nb)
+	}
+	fmt.Println("No problem found in", len(confs), "configs")
+	return nil
+}
+
+// Return the problems of the config and of its data files
+func validateConfig(c Config) []string {
+	var pbs []string
+	if err := c.prepare(); err != nil {
+		pbs = append(pbs, err.Error())
+	}
+	if len(c.abscis) != len(c.files) {
+		pbs = append(pbs, fmt.Sprintf("%d abscissa for %d files", len(c.abscis), len(c.files)))
+		return pbs
+	}
+	if c.abscisIsSz {
+		if _, err := sliceutil.StrToF64(c.abscis); err != nil {
+			pbs = append(pbs, fmt.Sprintf("the abscissa should be the message sizes : %v", err))
+		}
+	}
+	for _, f := range c.files {
+		info, err := os.Stat(f)
+		if err != nil {
+			pbs = append(pbs, err.Error())
+			continue
+		}
+		if info.Size() == 0 {
+			pbs = append(pbs, f+" is empty")
+			continue
+		}
+		ts1, ts2, err := parseData(f)
+		if err != nil {
+			pbs = append(pbs, fmt.Sprintf("%s : %v", f, err))
+			continue
+		}
+		if len(ts1) != len(ts2) {
+			pbs = append(pbs, fmt.Sprintf("%s : %d send times for %d receive times", f, len(ts1), len(ts2)))
+			continue
+		}
+		if len(ts1) <= c.nbPtsDiscard+1 {
+			pbs = append(pbs, fmt.Sprintf("%s : %d messages but %d discarded", f, len(ts1), c.nbPtsDiscard))
+			continue
+		}
+		neg := 0
+		for i := range ts1 {
+			if ts2[i] < ts1[i] {
+				neg++
+			}
+		}
+		if neg > 0 {
+			pbs = append(pbs, fmt.Sprintf("%s : %d negative latencies (clock skew, see the option -skew)", f, neg))
+		}
+	}
+	return pbs
+}
+
+// Return the problems of the comparison group
+func validateGroup(g CompareGroup) []string {
+	var pbs []string
+	confs, err := toConfigs(g.configs)
+	if err != nil {
+		return append(pbs, err.Error())
+	}
+	for _, c := range confs[1:] {
+		if c.xlabel != confs[0].xlabel {
+			pbs = append(pbs, fmt.Sprintf("%s has the abscissa %q, %s has %q", c.name, c.xlabel, confs[0].name, confs[0].xlabel))
+		}
+	}
+	return pbs
+}
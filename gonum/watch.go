@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"plots/plotfunc"
+	"strings"
+	"time"
+)
+
+// Interval in ms between two scans of the directories when inotify is not available (option -poll)
+var POLL = 2000
+
+// Time in ms without modification of the files before redrawing (option -quiet)
+var QUIET = 1000
+
+// Return the config restricted to its existing non empty data files
+func (c Config) available() Config {
+	c.prepare()
+	var abscis []string
+	for i, f := range c.files {
+		if info, err := os.Stat(f); err == nil && info.Size() > 0 {
+			abscis = append(abscis, c.abscis[i])
+		}
+	}
+	return c.withAbscissas(abscis)
+}
+
+// Return the config restricted to the data files of the given abscissas, in their order
+func (c Config) withAbscissas(abscis []string) Config {
+	c.prepare()
+	idx := make(map[string]int)
+	for i, a := range c.abscis {
+		idx[a] = i
+	}
+	var sufix []string
+	for _, a := range abscis {
+		sufix = append(sufix, c.sufix[idx[a]])
+	}
+	c.sufix, c.abscis = sufix, abscis
+	c.prepare()
+	return c
+}
+
+// Restrict the configs to the abscissas available in all of them, in the order of the first config
+// the comparisons draw the configs side by side by abscissa number
+func alignAbscissas(confs []Config) []Config {
+	aligned := make([]Config, len(confs))
+	count := make(map[string]int)
+	for i, c := range confs {
+		c.prepare()
+		for _, a := range c.abscis {
+			count[a]++
+		}
+		aligned[i] = c
+	}
+	var common []string
+	for _, a := range aligned[0].abscis {
+		if count[a] == len(confs) {
+			common = append(common, a)
+		}
+	}
+	for i, c := range aligned {
+		aligned[i] = c.withAbscissas(common)
+	}
+	return aligned
+}
+
+// Send the paths of the files created or modified in the directories, scanning them every interval
+func pollDirs(dirs []string, interval time.Duration, changed chan<- string) {
+	seen := make(map[string]os.FileInfo)
+	scan := func(send bool) {
+		for _, dir := range dirs {
+			infos, err := ioutil.ReadDir(dir)
+			if err != nil {
+				continue
+			}
+			for _, info := range infos {
+				f := filepath.Join(dir, info.Name())
+				old, found := seen[f]
+				if send && (!found || old.Size() != info.Size() || !old.ModTime().Equal(info.ModTime())) {
+					changed <- f
+				}
+				seen[f] = info
+			}
+		}
+	}
+	scan(false)
+	for range time.Tick(interval) {
+		scan(true)
+	}
+}
+
+// Watch the directories of the data files of the configs and redraw the diagrams "ds" of the configs
+// (and of the comparison groups containing them if compare is set) whose files are added or grow
+// the files are redrawn once they are not modified during QUIET ms, until ctx is cancelled
+func watch(ctx context.Context, confs []Config, ds []Draws, compare bool) error {
+	CACHE = true
+	// a failure must not cancel the redrawing of the other diagrams and configs
+	KEEPGOING = true
+	watched := make(map[string]bool) // data files of the configs
+	seen := make(map[string]bool)    // folders of the data files
+	var dirs []string
+	for _, c := range confs {
+		if err := c.prepare(); err != nil {
+			return err
+		}
+		for _, f := range c.files {
+			watched[f] = true
+			dir := filepath.Dir(f)
+			if seen[dir] {
+				continue
+			}
+			seen[dir] = true
+			if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+				fmt.Println("Warning : folder", dir, "of", c.name, "not found, not watched")
+				continue
+			}
+			dirs = append(dirs, dir)
+		}
+	}
+	if len(dirs) == 0 {
+		return errors.New("No folder to watch")
+	}
+	changed := make(chan string, 100)
+	go func() {
+		err := notifyDirs(dirs, changed)
+		fmt.Println("inotify unavailable (", err, "), polling the folders every", POLL, "ms")
+		pollDirs(dirs, time.Duration(POLL)*time.Millisecond, changed)
+	}()
+	fmt.Println("Watching", len(dirs), "folders:", strings.Join(dirs, " "))
+	pending := make(map[string]bool)
+	var quiet <-chan time.Time
+	for {
+		select {
+		case f := <-changed:
+			if watched[f] {
+				pending[f] = true
+				quiet = time.After(time.Duration(QUIET) * time.Millisecond)
+			}
+		case <-quiet:
+			redraw(ctx, confs, pending, ds, compare)
+			pending, quiet = make(map[string]bool), nil
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// Redraw the diagrams "ds" of the modified files, the summaries of their configs and the comparisons containing them
+// the failures are printed without stopping the other redrawings (KEEPGOING is set by watch) nor the watch
+func redraw(ctx context.Context, confs []Config, modified map[string]bool, ds []Draws, compare bool) {
+	affected := make(map[string]bool) // names of the redrawn configs
+	var tasks []task
+	for _, cfg := range confs {
+		c := cfg.available()
+		var idx []int
+		for i, f := range c.files {
+			if modified[f] {
+				idx = append(idx, i)
+			}
+		}
+		if len(idx) == 0 {
+			continue
+		}
+		affected[c.name] = true
+		fmt.Println("Redrawing", c.name, "for", len(idx), "modified files")
+		for _, d := range ds {
+			for _, i := range idx {
+				tasks = append(tasks, fileTasks(c, d, i)...)
+			}
+			tasks = append(tasks, summaryTasks(c, d)...)
+		}
+	}
+	plotfunc.N = 1
+	if err := runTasks(ctx, tasks); err != nil {
+		printErrors(err)
+	}
+	if !compare {
+		return
+	}
+	tasks = nil
+	for _, g := range CompareGroups {
+		found := false
+		for _, name := range g.configs {
+			found = found || affected[name]
+		}
+		if !found {
+			continue
+		}
+		cfgs, err := toConfigs(g.configs)
+		if err != nil {
+			fmt.Println("Error :", err)
+			continue
+		}
+		// the configs without data yet are not compared, the others are compared on their common abscissas
+		avail := cfgs[:0]
+		for _, c := range cfgs {
+			if c = c.available(); len(c.files) > 0 {
+				avail = append(avail, c)
+			}
+		}
+		if len(avail) == 0 {
+			continue
+		}
+		if avail = alignAbscissas(avail); len(avail[0].files) == 0 {
+			fmt.Println("No abscissa available in all the configs of the comparison", g.name)
+			continue
+		}
+		fmt.Println("Redrawing the comparison", g.name, "on", len(avail[0].files), "abscissas")
+		ts, err := compareTasks(g.name, avail, -1)
+		if err != nil {
+			fmt.Println("Error :", err)
+			continue
+		}
+		tasks = append(tasks, ts...)
+	}
+	ComparePNGsuffix = "per_partition"
+	plotfunc.N = 10
+	if err := runTasks(ctx, tasks); err != nil {
+		printErrors(err)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+)
+
+// Send the paths of the files created or modified in the directories, using inotify
+// blocks until an error occurs
+func notifyDirs(dirs []string, changed chan<- string) error {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC)
+	if err != nil {
+		return err
+	}
+	defer syscall.Close(fd)
+	wds := make(map[int32]string)
+	for _, dir := range dirs {
+		wd, err := syscall.InotifyAddWatch(fd, dir, syscall.IN_CREATE|syscall.IN_MODIFY|syscall.IN_CLOSE_WRITE|syscall.IN_MOVED_TO)
+		if err != nil {
+			return err
+		}
+		wds[int32(wd)] = dir
+	}
+	// room for at least one event with the longest file name
+	buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+	for {
+		n, err := syscall.Read(fd, buf)
+		if err == syscall.EINTR {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		for off := 0; off+syscall.SizeofInotifyEvent <= n; {
+			ev := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[off]))
+			start := off + syscall.SizeofInotifyEvent
+			name := string(bytes.TrimRight(buf[start:start+int(ev.Len)], "\x00"))
+			if dir, found := wds[ev.Wd]; found && name != "" {
+				changed <- filepath.Join(dir, name)
+			}
+			off = start + int(ev.Len)
+		}
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+// +build !linux
+
+package main
+
+import "errors"
+
+// inotify is only available on Linux, the directories are polled
+func notifyDirs(dirs []string, changed chan<- string) error {
+	return errors.New("inotify is only available on Linux")
+}
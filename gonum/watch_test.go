@@ -0,0 +1,35 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// The configs are restricted to their common abscissas, in the order of the first one
+func TestAlignAbscissas(t *testing.T) {
+	confs := []Config{
+		{name: "a", root: "/a", prefix: "f", sufix: []string{"1", "2", "3"}},
+		{name: "b", root: "/b", prefix: "g", sufix: []string{"3", "1"}},
+		{name: "c", root: "/c", prefix: "h", sufix: []string{"s1", "s3", "s4"}, abscis: []string{"1", "3", "4"}},
+	}
+	aligned := alignAbscissas(confs)
+	wanted := [][]string{
+		{filepath.Join("/a", "f1"), filepath.Join("/a", "f3")},
+		{filepath.Join("/b", "g1"), filepath.Join("/b", "g3")},
+		{filepath.Join("/c", "hs1"), filepath.Join("/c", "hs3")},
+	}
+	for i, c := range aligned {
+		if !reflect.DeepEqual(c.abscis, []string{"1", "3"}) {
+			t.Errorf("Bad abscissas of %s: wanted: [1 3] found: %v", c.name, c.abscis)
+		}
+		if !reflect.DeepEqual(c.files, wanted[i]) {
+			t.Errorf("Bad files of %s: wanted: %v found: %v", c.name, wanted[i], c.files)
+		}
+	}
+	// no common abscissa
+	aligned = alignAbscissas([]Config{confs[0], {name: "d", sufix: []string{"5"}}})
+	if len(aligned[0].files) != 0 || len(aligned[1].files) != 0 {
+		t.Errorf("Files without common abscissa: %v %v", aligned[0].files, aligned[1].files)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"math"
 	"math/rand"
 	"plots/stats"
+	"strconv"
 	"time"
 
 	"gonum.org/v1/plot"
@@ -159,14 +160,19 @@ type errPoints struct {
 
 // AddWithErrXY Draw the data (x, y) with their error bars (devs)
 func AddWithErrXY(x, y, devs []float64, legend string, n int, p *plot.Plot) error {
+	return AddWithAsymErrXY(x, y, devs, devs, legend, n, p)
+}
+
+// AddWithAsymErrXY Draw the data (x, y) with asymmetric error bars from y - low to y + high
+func AddWithAsymErrXY(x, y, low, high []float64, legend string, n int, p *plot.Plot) error {
 	rand.Seed(time.Now().UnixNano())
 	xys := make(plotter.XYs, len(y))
 	yer := make(plotter.YErrors, len(y))
 	for j := range xys {
 		xys[j].X = x[j]
 		xys[j].Y = y[j]
-		yer[j].High = devs[j]
-		yer[j].Low = devs[j]
+		yer[j].High = high[j]
+		yer[j].Low = low[j]
 	}
 	data := errPoints{XYs: xys, YErrors: yer}
 	yerrs, err := plotter.NewYErrorBars(data)
@@ -202,6 +208,20 @@ func AddWithPointsXY(x, y []float64, legend string, n int, p *plot.Plot) error {
 	return nil
 }
 
+// AddOutliers highlights the points (x, y) with black crosses
+func AddOutliers(x, y []float64, legend string, p *plot.Plot) error {
+	points, err := plotter.NewScatter(CreatePointsXY(x, y))
+	if err != nil {
+		return err
+	}
+	points.Radius = 3
+	points.Shape = draw.CrossGlyph{}
+	points.Color = BLACK
+	p.Add(points)
+	addLegend(legend, p, points, true, 0)
+	return nil
+}
+
 // AddChart Draw the data in a barchart
 func AddBarChart(x []string, y []float64, xlabel []string, p *plot.Plot) error {
 	width := 10.
@@ -269,29 +289,6 @@ func CreatePointsXY(x, y []float64) plotter.XYs {
 	return pts
 }
 
-// Interpolation of x, y (with deviations devs) with a polynom of degree n
-// Returns the fitting coefficients
-func AddPolyfit(x, y, devs []float64, n int, p *plot.Plot) ([]float64, error) {
-	// Add regression trinome
-	ia := make([]bool, n)
-	cofs := make([]float64, n)
-	for i := 0; i < n; i++ {
-		ia[i] = true
-		cofs[i] = 1.
-	}
-	if _, _, err := stats.Lfit(x, y, devs, cofs, ia, n, stats.Fcoefs); err != nil {
-		return nil, err
-	}
-	poly := func(x float64) float64 {
-		return stats.Fpoly(x, cofs)
-	}
-	fp := plotter.NewFunction(poly)
-	fp.Color = color.RGBA{B: 255, A: 255}
-	fp.Dashes = []vg.Length{vg.Points(10), vg.Points(10)}
-	p.Add(fp)
-	return cofs, nil
-}
-
 // Interpolation of x, y with a straight line
 // Return a, b, siga, sigb, chi2 and sigdat
 func AddLinearfit(x, y []float64, p *plot.Plot) (float64, float64, float64, float64, float64, float64) {
@@ -315,3 +312,280 @@ func AddGaussian(mean, sdev float64, p *plot.Plot) {
 	gaus.Color = color.RGBA{B: 255, A: 255}
 	p.Add(gaus)
 }
+
+// gridXYZ implements plotter.GridXYZ on a [col][row] slice
+type gridXYZ struct {
+	x, y []float64
+	z    [][]float64
+}
+
+func (g gridXYZ) Dims() (int, int)   { return len(g.x), len(g.y) }
+func (g gridXYZ) Z(c, r int) float64 { return g.z[c][r] }
+func (g gridXYZ) X(c int) float64    { return g.x[c] }
+func (g gridXYZ) Y(r int) float64    { return g.y[r] }
+
+// pow10Ticks labels an axis holding log10 values with the corresponding powers of 10
+type pow10Ticks struct{}
+
+// Ticks computes the default tick marks on the log10 values, and label them with 10^value
+func (pow10Ticks) Ticks(min, max float64) []plot.Tick {
+	tks := plot.DefaultTicks{}.Ticks(min, max)
+	for i, t := range tks {
+		if t.Label == "" { // Skip minor ticks, they are fine.
+			continue
+		}
+		tks[i].Label = fmt.Sprintf("%.3g", math.Pow(10, t.Value))
+	}
+	return tks
+}
+
+// AddHeatMap Draw the counts z[0..len(x)-1][0..len(y)-1] as a heat map
+// x, y are the centers of the cells, the y values are plotted on a log10 scale
+// the colour is proportional to log10 of the counts, empty cells are left blank
+func AddHeatMap(x, y []float64, z [][]float64, p *plot.Plot) error {
+	if len(x) == 0 || len(y) == 0 {
+		return errors.New("AddHeatMap: empty grid")
+	}
+	ly := make([]float64, len(y))
+	for j := range y {
+		if y[j] <= 0 {
+			return errors.New("AddHeatMap: y values must be positive")
+		}
+		ly[j] = math.Log10(y[j])
+	}
+	lz := make([][]float64, len(z))
+	max := 0.
+	for i := range z {
+		lz[i] = make([]float64, len(z[i]))
+		for j, v := range z[i] {
+			lz[i][j] = math.Inf(-1)
+			if v > 0 {
+				lz[i][j] = math.Log10(v)
+			}
+			if lz[i][j] > max {
+				max = lz[i][j]
+			}
+		}
+	}
+	hm := plotter.NewHeatMap(gridXYZ{x: x, y: ly, z: lz}, moreland.SmoothBlueRed().Palette(255))
+	hm.Min = 0
+	hm.Max = math.Max(max, 1)
+	p.Add(hm)
+	p.Y.Tick.Marker = pow10Ticks{}
+	return nil
+}
+
+// AddBoxPlot Draw a box-and-whisker plot of the data at the abscissa loc
+// offset : shift of the box from loc, used to group several boxes at the same abscissa
+func AddBoxPlot(data []float64, loc float64, offset vg.Length, legend string, n int, p *plot.Plot) error {
+	box, err := plotter.NewBoxPlot(vg.Points(15), loc, plotter.Values(data))
+	if err != nil {
+		return err
+	}
+	c := getColor(n)
+	box.Offset = offset
+	box.BoxStyle.Color = c
+	box.MedianStyle.Color = c
+	box.WhiskerStyle.Color = c
+	box.GlyphStyle.Color = c
+	box.GlyphStyle.Radius = 1
+	p.Add(box)
+	addLegend(legend, p, colorThumbnail{c}, true, 0)
+	return nil
+}
+
+// colorThumbnail draws a legend thumbnail filled with a color
+type colorThumbnail struct {
+	color.Color
+}
+
+// Thumbnail fills the legend thumbnail with the color
+func (t colorThumbnail) Thumbnail(c *draw.Canvas) {
+	pts := []vg.Point{
+		{X: c.Min.X, Y: c.Min.Y},
+		{X: c.Min.X, Y: c.Max.Y},
+		{X: c.Max.X, Y: c.Max.Y},
+		{X: c.Max.X, Y: c.Min.Y},
+	}
+	c.FillPolygon(t.Color, c.ClipPolygonY(pts))
+}
+
+// AddViolin Draw a violin plot centered on the abscissa loc
+// y, dens : the density estimation of the data evaluated at y
+// width : the maximum width of the violin in data units
+// median : the median of the data, drawn as a short horizontal line
+func AddViolin(y, dens []float64, loc, width, median float64, legend string, n int, p *plot.Plot) error {
+	max := 0.
+	for _, d := range dens {
+		if d > max {
+			max = d
+		}
+	}
+	if max == 0 {
+		return errors.New("AddViolin: empty density")
+	}
+	scale := width / (2. * max)
+	pts := make(plotter.XYs, 2*len(y))
+	for i := range y {
+		pts[i].X = loc - dens[i]*scale
+		pts[i].Y = y[i]
+		j := len(pts) - 1 - i
+		pts[j].X = loc + dens[i]*scale
+		pts[j].Y = y[i]
+	}
+	poly, err := plotter.NewPolygon(pts)
+	if err != nil {
+		return err
+	}
+	c := getColor(n)
+	poly.Color = c
+	poly.LineStyle.Color = c
+	p.Add(poly)
+	addLegend(legend, p, poly, true, 0)
+	return AddStraightLine(loc-width/4., median, loc+width/4., median, "", BLACK, p)
+}
+
+// SetLogX Use a logarithmic scale on the X axis (all the data must be positive)
+func SetLogX(p *plot.Plot) {
+	p.X.Scale = plot.LogScale{}
+	p.X.Tick.Marker = plot.LogTicks{}
+}
+
+// SetLogY Use a logarithmic scale on the Y axis (all the data must be positive)
+func SetLogY(p *plot.Plot) {
+	p.Y.Scale = plot.LogScale{}
+	p.Y.Tick.Marker = plot.LogTicks{}
+}
+
+// percentileTicks labels an axis holding 1 / (1 - p) with the percentiles p : 50%, 90%, 99%, 99.9% ...
+type percentileTicks struct{}
+
+// Ticks marks 1 / (1 - p) = 2 and the powers of 10
+func (percentileTicks) Ticks(min, max float64) []plot.Tick {
+	var tks []plot.Tick
+	if min <= 2 && max >= 2 {
+		tks = append(tks, plot.Tick{Value: 2, Label: "50%"})
+	}
+	for v := 10.; v <= max; v *= 10 {
+		if v >= min {
+			tks = append(tks, plot.Tick{Value: v, Label: strconv.FormatFloat(100-100/v, 'f', -1, 64) + "%"})
+		}
+	}
+	return tks
+}
+
+// SetPercentileX Use a logarithmic scale on the X axis holding 1 / (1 - p), labelled with the percentiles p
+func SetPercentileX(p *plot.Plot) {
+	p.X.Scale = plot.LogScale{}
+	p.X.Tick.Marker = percentileTicks{}
+}
+
+// AddHistoEdges Draw the histogram of bins [edges[i], edges[i+1]] with the heights histo[i] (bins of any width)
+func AddHistoEdges(edges, histo []float64, p *plot.Plot) error {
+	if len(edges) != len(histo)+1 {
+		return errors.New("AddHistoEdges: need len(histo) + 1 edges")
+	}
+	bins := make([]plotter.HistogramBin, len(histo))
+	for i := range bins {
+		bins[i] = plotter.HistogramBin{Min: edges[i], Max: edges[i+1], Weight: histo[i]}
+	}
+	p.Add(&plotter.Histogram{
+		Bins:      bins,
+		Width:     (edges[len(edges)-1] - edges[0]) / float64(len(histo)),
+		FillColor: color.Gray{128},
+		LineStyle: plotter.DefaultLineStyle,
+	})
+	return nil
+}
+
+// AddDistribution Add the probability density function of the distribution d
+func AddDistribution(d stats.Distribution, legend string, p *plot.Plot) {
+	pdf := plotter.NewFunction(d.Pdf)
+	pdf.Color = color.RGBA{B: 255, A: 255}
+	p.Add(pdf)
+	addLegend(legend, p, pdf, true, 0)
+}
+
+// Interpolation of x, y (with deviations devs) with the nonlinear model "funcs" (Levenberg-Marquardt)
+// a holds the initial guesses of the parameters and is replaced by the fitted values
+// Returns chi2 and the covariance matrix of the parameters
+func AddNonLinearfit(x, y, devs, a []float64, funcs stats.Fmodel, p *plot.Plot) (float64, [][]float64, error) {
+	ia := make([]bool, len(a))
+	for i := range ia {
+		ia[i] = true
+	}
+	chi2, covar, err := stats.Mrqmin(x, y, devs, a, ia, funcs)
+	if err != nil {
+		return 0., nil, err
+	}
+	model := func(x float64) float64 {
+		y, _ := funcs(x, a)
+		return y
+	}
+	fp := plotter.NewFunction(model)
+	fp.Color = color.RGBA{B: 255, A: 255}
+	fp.Dashes = []vg.Length{vg.Points(10), vg.Points(10)}
+	p.Add(fp)
+	return chi2, covar, nil
+}
+
+// AddBand Draw the area between the curves (x, low) and (x, high) as a shaded band
+func AddBand(x, low, high []float64, legend string, c color.Color, p *plot.Plot) error {
+	pts := make(plotter.XYs, 2*len(x))
+	for i := range x {
+		pts[i].X = x[i]
+		pts[i].Y = low[i]
+		j := len(pts) - 1 - i
+		pts[j].X = x[i]
+		pts[j].Y = high[i]
+	}
+	poly, err := plotter.NewPolygon(pts)
+	if err != nil {
+		return err
+	}
+	poly.Color = c
+	poly.LineStyle.Width = 0
+	p.Add(poly)
+	addLegend(legend, p, poly, false, 120)
+	return nil
+}
+
+// AddPolyBands Draw the polynomial fit f between xmin and xmax
+// with its confidence band (shaded) and its prediction band (dashed lines)
+func AddPolyBands(f stats.PolyFit, xmin, xmax float64, p *plot.Plot) error {
+	const n = 100
+	x := make([]float64, n)
+	y := make([]float64, n)
+	clow := make([]float64, n)
+	chigh := make([]float64, n)
+	plow := make([]float64, n)
+	phigh := make([]float64, n)
+	for i := range x {
+		x[i] = xmin + (xmax-xmin)*float64(i)/float64(n-1)
+		y[i] = f.Eval(x[i])
+		conf, pred := f.Bands(x[i])
+		clow[i], chigh[i] = y[i]-conf, y[i]+conf
+		plow[i], phigh[i] = y[i]-pred, y[i]+pred
+	}
+	if err := AddBand(x, clow, chigh, "", color.NRGBA{B: 255, G: 128, A: 60}, p); err != nil {
+		return err
+	}
+	dashes := []vg.Length{vg.Points(5), vg.Points(5)}
+	for _, yy := range [][]float64{plow, phigh} {
+		line, err := plotter.NewLine(CreatePointsXY(x, yy))
+		if err != nil {
+			return err
+		}
+		line.Color = color.RGBA{B: 255, A: 255}
+		line.Dashes = dashes
+		p.Add(line)
+	}
+	fit, err := plotter.NewLine(CreatePointsXY(x, y))
+	if err != nil {
+		return err
+	}
+	fit.Color = color.RGBA{B: 255, A: 255}
+	p.Add(fit)
+	addLegend(fmt.Sprintf("degree %d", f.Degree), p, fit, false, 120)
+	return nil
+}
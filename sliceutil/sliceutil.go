@@ -53,11 +53,9 @@ func MapF64(data []float64, f func(float64) float64) []float64 {
 func FilterF64(data []float64, f func(float64) bool) []float64 {
 	f64 := make([]float64, 0)
 	var v float64
-	var i int = 0
-	for i, v = range data {
+	for _, v = range data {
 		if f(v) {
-			f64[i] = v
-			i++
+			f64 = append(f64, v)
 		}
 	}
 	return f64
@@ -0,0 +1,21 @@
+package sliceutil
+
+import (
+	"reflect"
+	"testing"
+)
+
+// The kept values are appended in order, whatever their number
+func TestFilterF64(t *testing.T) {
+	positive := func(v float64) bool { return v > 0 }
+	for _, c := range []struct{ data, wanted []float64 }{
+		{[]float64{1, -2, 3, -4, 5}, []float64{1, 3, 5}},
+		{[]float64{-1, -2}, []float64{}},
+		{[]float64{1, 2}, []float64{1, 2}},
+		{nil, []float64{}},
+	} {
+		if res := FilterF64(c.data, positive); !reflect.DeepEqual(res, c.wanted) {
+			t.Errorf("Bad filter of %v: wanted: %v found: %v", c.data, c.wanted, res)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package stats
+
+import (
+	"errors"
+	"math"
+)
+
+// Return the autocorrelations rho[0..maxlag] of the data of mean "mean"
+// computed with the FFT (zero padded to avoid the circular wrap around) in O(n log n)
+// Returns nil if the data have no variance
+func autocorrFFT(data []float64, mean float64, maxlag int) []float64 {
+	m := 1
+	for m < 2*len(data) {
+		m <<= 1
+	}
+	re := make([]float64, m)
+	im := make([]float64, m)
+	for i, d := range data {
+		re[i] = d - mean
+	}
+	fft(re, im)
+	// the power spectrum is real and even : its forward transform is m times the autocovariance
+	for k := range re {
+		re[k], im[k] = re[k]*re[k]+im[k]*im[k], 0
+	}
+	fft(re, im)
+	if re[0] <= 0 {
+		return nil
+	}
+	rho := make([]float64, maxlag+1)
+	for k := range rho {
+		rho[k] = re[k] / re[0]
+	}
+	return rho
+}
+
+// Autocorr returns the autocorrelation function rho[0..maxlag] of the data
+func Autocorr(data []float64, maxlag int) ([]float64, error) {
+	n := len(data)
+	if n < 2 || maxlag < 0 || maxlag >= n {
+		return nil, errors.New("Autocorr: need n >= 2 and 0 <= maxlag < n")
+	}
+	rho := autocorrFFT(data, MeanF64(data), maxlag)
+	if rho == nil {
+		return nil, errors.New("Autocorr: no variance")
+	}
+	return rho, nil
+}
+
+// EffectiveSampleSize returns the number of independent samples equivalent to the correlated data
+// n / tau with the integrated autocorrelation time tau = 1 + 2 sum{k} rho_k
+// truncated with Geyer's initial positive sequence (sum of pairs rho_2k + rho_2k+1 while positive)
+// and at the lag min(maxlag, n/2)
+func EffectiveSampleSize(data []float64, maxlag int) (float64, error) {
+	n := len(data)
+	if n < 4 || maxlag < 1 {
+		return 0, errors.New("EffectiveSampleSize: need n >= 4 and maxlag >= 1")
+	}
+	if maxlag > n/2 {
+		maxlag = n / 2
+	}
+	rho := autocorrFFT(data, MeanF64(data), maxlag)
+	if rho == nil {
+		return 0, errors.New("EffectiveSampleSize: no variance")
+	}
+	tau := -1.
+	for k := 0; k+1 < len(rho); k += 2 {
+		pair := rho[k] + rho[k+1]
+		if pair <= 0 {
+			break
+		}
+		tau += 2. * pair
+	}
+	if tau < 1./float64(n) {
+		tau = 1. / float64(n)
+	}
+	return math.Min(float64(n)/tau, float64(n)), nil
+}
+
+// BatchMeans splits the data into nbatch consecutive batches of equal size (the remaining points are dropped)
+// and returns the mean of the data and its standard error estimated from the dispersion of the batch means
+func BatchMeans(data []float64, nbatch int) (float64, float64, error) {
+	if nbatch < 2 || len(data) < nbatch {
+		return 0, 0, errors.New("BatchMeans: need 2 <= nbatch <= n")
+	}
+	size := len(data) / nbatch
+	means := make([]float64, nbatch)
+	for b := range means {
+		means[b] = MeanF64(data[b*size : (b+1)*size])
+	}
+	mean := MeanF64(means)
+	v := 0.
+	for _, m := range means {
+		v += (m - mean) * (m - mean)
+	}
+	v /= float64(nbatch - 1)
+	return mean, math.Sqrt(v / float64(nbatch)), nil
+}
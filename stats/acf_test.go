@@ -0,0 +1,101 @@
+package stats
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// Generate an AR(1) series x_i = phi x_i-1 + noise
+func ar1(n int, phi float64, seed int64) []float64 {
+	r := rand.New(rand.NewSource(seed))
+	data := make([]float64, n)
+	for i := 1; i < n; i++ {
+		data[i] = phi*data[i-1] + r.NormFloat64()
+	}
+	return data
+}
+
+// The autocorrelation of an AR(1) series at lag k is phi^k
+func TestAutocorr(t *testing.T) {
+	rho, err := Autocorr(ar1(50000, 0.8, 1), 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k := range rho {
+		if math.Abs(rho[k]-math.Pow(0.8, float64(k))) > 0.03 {
+			t.Errorf("Bad autocorrelation at lag %d: wanted: %f found: %f", k, math.Pow(0.8, float64(k)), rho[k])
+		}
+	}
+}
+
+// The autocorrelations computed with the FFT are the direct sums
+func TestAutocorrDirect(t *testing.T) {
+	data := ar1(1000, 0.5, 3)
+	for i := range data {
+		data[i] += 0.01 * float64(i)
+	}
+	rho, err := Autocorr(data, 999)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mean := MeanF64(data)
+	c0 := 0.
+	for _, d := range data {
+		c0 += (d - mean) * (d - mean)
+	}
+	for k := range rho {
+		s := 0.
+		for i := 0; i+k < len(data); i++ {
+			s += (data[i] - mean) * (data[i+k] - mean)
+		}
+		if math.Abs(rho[k]-s/c0) > 1e-9 {
+			t.Fatalf("Bad autocorrelation at lag %d: wanted: %g found: %g", k, s/c0, rho[k])
+		}
+	}
+	if _, err = Autocorr(make([]float64, 10), 2); err == nil {
+		t.Error("No error for data without variance")
+	}
+}
+
+// The effective sample size of an AR(1) series is n (1 - phi) / (1 + phi)
+func TestEffectiveSampleSize(t *testing.T) {
+	n := 50000
+	ess, err := EffectiveSampleSize(ar1(n, 0.8, 2), 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wanted := float64(n) * 0.2 / 1.8
+	if math.Abs(ess-wanted) > 0.2*wanted {
+		t.Errorf("Bad effective sample size: wanted: %f found: %f", wanted, ess)
+	}
+	// and the standard error of the batch means is the one of the effective sample size
+	_, stderr, err := BatchMeans(ar1(n, 0.8, 2), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wanted = math.Sqrt(1./(1.-0.64)) / math.Sqrt(wanted)
+	if math.Abs(stderr-wanted) > 0.3*wanted {
+		t.Errorf("Bad batch means stderr: wanted: %f found: %f", wanted, stderr)
+	}
+}
+
+// With a trend, the pairs of autocorrelations stay positive up to the maximum lag
+func TestEffectiveSampleSizeTrend(t *testing.T) {
+	n := 100000
+	data := ar1(n, 0.5, 4)
+	for i := range data {
+		data[i] += 1e-3 * float64(i)
+	}
+	ess, err := EffectiveSampleSize(data, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// tau <= 1 + 2 * 10
+	if ess < float64(n)/21. || ess > float64(n) {
+		t.Errorf("Bad effective sample size capped at lag 10: %f", ess)
+	}
+	if _, err = EffectiveSampleSize(data[:3], 10); err == nil {
+		t.Error("No error for 3 points")
+	}
+}
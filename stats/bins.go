@@ -0,0 +1,92 @@
+package stats
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// Rule giving the number of bins of an histogram
+type BinRule int
+
+const (
+	BinFD      BinRule = iota // Freedman-Diaconis : width 2 IQR n^(-1/3)
+	BinScott                  // Scott : width 3.49 sdev n^(-1/3)
+	BinSturges                // Sturges : 1 + log2(n) bins
+)
+
+func (r BinRule) String() string {
+	return [...]string{"fd", "scott", "sturges"}[r]
+}
+
+// Maximum number of bins given by the rules (the width rules explode with long tails)
+const maxBins = 1000
+
+// NumBins returns the number of bins of the histogram of the data according to the rule, at least 1
+func NumBins(data []float64, r BinRule) int {
+	n := len(data)
+	if n < 2 {
+		return 1
+	}
+	min, max := data[0], data[0]
+	for _, v := range data {
+		min = math.Min(min, v)
+		max = math.Max(max, v)
+	}
+	var width float64
+	switch r {
+	case BinSturges:
+		return int(math.Ceil(math.Log2(float64(n)))) + 1
+	case BinFD:
+		width = 2 * (Quantile(data, 0.75) - Quantile(data, 0.25)) * math.Cbrt(1./float64(n))
+	case BinScott:
+		_, _, sdev, _, _, err := Moments(data)
+		if err != nil {
+			return 1
+		}
+		width = 3.49 * sdev * math.Cbrt(1./float64(n))
+	}
+	if width <= 0 {
+		return 1
+	}
+	nb := int(math.Ceil((max - min) / width))
+	if nb < 1 {
+		return 1
+	}
+	if nb > maxBins {
+		return maxBins
+	}
+	return nb
+}
+
+// LinearBins returns the n+1 edges of n bins of equal width between min and max
+func LinearBins(min, max float64, n int) ([]float64, error) {
+	if max <= min || n < 1 {
+		return nil, errors.New("LinearBins: need min < max and n > 0")
+	}
+	edges := make([]float64, n+1)
+	for i := range edges {
+		edges[i] = min + (max-min)*float64(i)/float64(n)
+	}
+	edges[n] = max
+	return edges, nil
+}
+
+// ToHistoEdges returns the normalized histogram (density) of the data in the bins defined by the sorted edges :
+// the number of values in each bin divided by the number of values and by the width of the bin
+// the values outside the bins are counted in the normalization
+func ToHistoEdges(data, edges []float64) []float64 {
+	if !sort.Float64sAreSorted(edges) || len(edges) < 2 {
+		return nil
+	}
+	histo := make([]float64, len(edges)-1)
+	for _, v := range data {
+		if i := binIndex(v, edges); i >= 0 {
+			histo[i]++
+		}
+	}
+	for i := range histo {
+		histo[i] /= float64(len(data)) * (edges[i+1] - edges[i])
+	}
+	return histo
+}
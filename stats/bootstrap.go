@@ -0,0 +1,74 @@
+package stats
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// A statistic computed on a sample
+type Statistic func([]float64) float64
+
+// MeanF64 is the mean of the data
+func MeanF64(data []float64) float64 {
+	if len(data) == 0 {
+		return 0
+	}
+	s := 0.
+	for _, d := range data {
+		s += d
+	}
+	return s / float64(len(data))
+}
+
+// Percentile returns the statistic computing the quantile p (in [0, 1]) of the data
+func Percentile(p float64) Statistic {
+	return func(data []float64) float64 {
+		return Quantile(data, p)
+	}
+}
+
+// Bootstrap computes the statistic of the data and its (1 - alpha) percentile confidence interval
+// from nboot resamples drawn with replacement (the data are supposed independent)
+// Returns the statistic, the lower and the upper bounds of the interval
+func Bootstrap(data []float64, stat Statistic, nboot int, alpha float64, r *rand.Rand) (float64, float64, float64, error) {
+	return BlockBootstrap(data, 1, stat, nboot, alpha, r)
+}
+
+// BlockBootstrap computes the statistic of the data and its (1 - alpha) percentile confidence interval
+// from nboot moving block resamples: each resample concatenates blocks of "block" consecutive values
+// starting at random positions, which preserves the correlation of time series within the blocks
+// Returns the statistic, the lower and the upper bounds of the interval
+func BlockBootstrap(data []float64, block int, stat Statistic, nboot int, alpha float64, r *rand.Rand) (float64, float64, float64, error) {
+	n := len(data)
+	if n < 2 {
+		return 0, 0, 0, errors.New("BlockBootstrap: n must be at least 2")
+	}
+	if block < 1 || block > n {
+		return 0, 0, 0, errors.New("BlockBootstrap: block must be in [1, n]")
+	}
+	if nboot < 1 || alpha <= 0 || alpha >= 1 {
+		return 0, 0, 0, errors.New("BlockBootstrap: need nboot > 0 and alpha in ]0, 1[")
+	}
+	boot := make([]float64, nboot)
+	resample := make([]float64, n)
+	for b := range boot {
+		for i := 0; i < n; i += block {
+			start := r.Intn(n - block + 1)
+			copy(resample[i:], data[start:start+block])
+		}
+		boot[b] = stat(resample)
+	}
+	sort.Float64s(boot)
+	return stat(data), quantileSorted(boot, alpha/2.), quantileSorted(boot, 1.-alpha/2.), nil
+}
+
+// BlockLength returns the rule-of-thumb length n^(1/3) of the blocks of the block bootstrap
+func BlockLength(n int) int {
+	l := int(math.Round(math.Cbrt(float64(n))))
+	if l < 1 {
+		return 1
+	}
+	return l
+}
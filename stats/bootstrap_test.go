@@ -0,0 +1,47 @@
+package stats
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// The bootstrap interval of the mean of normal samples should be close to +/- 1.96 sdev / sqrt(n)
+func TestBootstrapMean(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	data := make([]float64, 2000)
+	for i := range data {
+		data[i] = 10. + 2.*r.NormFloat64()
+	}
+	mean, lo, hi, err := Bootstrap(data, MeanF64, 2000, 0.05, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	half := 1.96 * 2. / math.Sqrt(2000.)
+	if lo > mean || hi < mean {
+		t.Errorf("Mean outside its interval: %f [%f, %f]", mean, lo, hi)
+	}
+	if math.Abs((hi-lo)/2.-half) > 0.2*half {
+		t.Errorf("Bad half width: wanted: %f found: %f", half, (hi-lo)/2.)
+	}
+}
+
+// The block bootstrap interval of the mean of an autocorrelated series should be wider
+func TestBlockBootstrapMean(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	data := make([]float64, 5000)
+	for i := 1; i < len(data); i++ {
+		data[i] = 0.9*data[i-1] + r.NormFloat64()
+	}
+	_, lo, hi, err := Bootstrap(data, MeanF64, 1000, 0.05, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, blo, bhi, err := BlockBootstrap(data, 50, MeanF64, 1000, 0.05, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bhi-blo < 2*(hi-lo) {
+		t.Errorf("Block interval not wide enough: plain: %f block: %f", hi-lo, bhi-blo)
+	}
+}
@@ -0,0 +1,263 @@
+package stats
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// Method used to detect the change points
+type ChangeMethod int
+
+const (
+	CpPELT   ChangeMethod = iota // pruned exact linear time : optimal segmentation for the penalty
+	CpBinSeg                     // binary segmentation : recursive best split while it lowers the penalized cost
+	CpCUSUM                      // recursive CUSUM test of a single change at the 5% level
+)
+
+func (m ChangeMethod) String() string {
+	return [...]string{"pelt", "binseg", "cusum"}[m]
+}
+
+// 5% critical value of the supremum of a Brownian bridge (Kolmogorov distribution)
+const cusumCrit = 1.358
+
+// Cost of a segment of gaussian data : the weighted sum of squared residuals of the weighted straight line
+// fitted on the segment (or of its weighted mean if there is no abscissa), computed in O(1) with prefix sums
+type segCost struct {
+	s, sx, sxx, sy, sxy, syy []float64 // prefix sums of w, w x, w x^2, w y, w x y and w y^2 with w = 1 / sig^2
+}
+
+// x nil : the cost of a change of the mean
+func newSegCost(x, y, sig []float64) segCost {
+	n := len(y)
+	c := segCost{make([]float64, n+1), make([]float64, n+1), make([]float64, n+1),
+		make([]float64, n+1), make([]float64, n+1), make([]float64, n+1)}
+	for i, v := range y {
+		w := 1. / (sig[i] * sig[i])
+		xi := 0.
+		if x != nil {
+			xi = x[i]
+		}
+		c.s[i+1] = c.s[i] + w
+		c.sx[i+1] = c.sx[i] + w*xi
+		c.sxx[i+1] = c.sxx[i] + w*xi*xi
+		c.sy[i+1] = c.sy[i] + w*v
+		c.sxy[i+1] = c.sxy[i] + w*xi*v
+		c.syy[i+1] = c.syy[i] + w*v*v
+	}
+	return c
+}
+
+// intercept and slope of the line fitted on the segment y[i..j-1]
+// a single abscissa gives a null slope
+func (c segCost) fit(i, j int) (float64, float64) {
+	s := c.s[j] - c.s[i]
+	sx := c.sx[j] - c.sx[i]
+	sy := c.sy[j] - c.sy[i]
+	del := s*(c.sxx[j]-c.sxx[i]) - sx*sx
+	if del <= TOL*s*(c.sxx[j]-c.sxx[i]) {
+		return sy / s, 0
+	}
+	b := (s*(c.sxy[j]-c.sxy[i]) - sx*sy) / del
+	return (sy - b*sx) / s, b
+}
+
+// cost of the segment y[i..j-1]
+func (c segCost) cost(i, j int) float64 {
+	a, b := c.fit(i, j)
+	// sum of w (y - a - b x)^2 expanded
+	s, sx, sxx := c.s[j]-c.s[i], c.sx[j]-c.sx[i], c.sxx[j]-c.sxx[i]
+	sy, sxy, syy := c.sy[j]-c.sy[i], c.sxy[j]-c.sxy[i], c.syy[j]-c.syy[i]
+	v := syy - 2*a*sy - 2*b*sxy + a*a*s + 2*a*b*sx + b*b*sxx
+	if v < 0 {
+		// rounding errors
+		return 0
+	}
+	return v
+}
+
+// NoiseSdev estimates the standard deviation of the noise of a piecewise constant series
+// from the median absolute deviation of its first differences (robust to the changes of the mean)
+func NoiseSdev(y []float64) float64 {
+	if len(y) < 2 {
+		return 0
+	}
+	d := make([]float64, len(y)-1)
+	for i := range d {
+		d[i] = y[i+1] - y[i]
+	}
+	_, mad := MAD(d)
+	return mad / math.Sqrt2
+}
+
+// PELT returns the change points (starts of the new segments) minimizing the sum of the segment costs
+// plus pen per change point, the segments being at least minseg long (Killick et al. 2012)
+// x nil : changes of the mean, else changes of the straight line fitting y(x)
+func PELT(x, y, sig []float64, pen float64, minseg int) []int {
+	n := len(y)
+	c := newSegCost(x, y, sig)
+	f := make([]float64, n+1) // f[t] optimal cost of y[0..t-1]
+	last := make([]int, n+1)  // start of the last segment of the optimal segmentation of y[0..t-1]
+	f[0] = -pen
+	var cands []int
+	for t := minseg; t <= n; t++ {
+		// the segmentations ending at t-minseg become candidates
+		if s := t - minseg; s == 0 || s >= minseg {
+			cands = append(cands, s)
+		}
+		best, arg := math.Inf(1), 0
+		for _, s := range cands {
+			if v := f[s] + c.cost(s, t) + pen; v < best {
+				best, arg = v, s
+			}
+		}
+		f[t], last[t] = best, arg
+		// pruning : a candidate that cannot be optimal now will never be
+		kept := cands[:0]
+		for _, s := range cands {
+			if f[s]+c.cost(s, t) <= f[t] {
+				kept = append(kept, s)
+			}
+		}
+		cands = kept
+	}
+	var cps []int
+	for t := last[n]; t > 0; t = last[t] {
+		cps = append(cps, t)
+	}
+	sort.Ints(cps)
+	return cps
+}
+
+// BinSeg returns the change points found by binary segmentation : each segment is split at the point
+// lowering the most its cost while the decrease is greater than pen, the segments being at least minseg long
+// x nil : changes of the mean, else changes of the straight line fitting y(x)
+func BinSeg(x, y, sig []float64, pen float64, minseg int) []int {
+	c := newSegCost(x, y, sig)
+	var cps []int
+	var rec func(i, j int)
+	rec = func(i, j int) {
+		full := c.cost(i, j)
+		best, arg := 0., -1
+		for k := i + minseg; k <= j-minseg; k++ {
+			if gain := full - c.cost(i, k) - c.cost(k, j); gain > best {
+				best, arg = gain, k
+			}
+		}
+		if arg < 0 || best <= pen {
+			return
+		}
+		cps = append(cps, arg)
+		rec(i, arg)
+		rec(arg, j)
+	}
+	rec(0, len(y))
+	sort.Ints(cps)
+	return cps
+}
+
+// CUSUM returns the change points found by testing recursively a single change in each segment :
+// the maximum of the cumulative sums S_k of the residuals to the mean (x nil) or to the fitted straight line
+// (OLS-CUSUM test) normalized by sdev sqrt(n) is compared to the 5% critical value of the Kolmogorov distribution,
+// sdev being the noise standard deviation
+func CUSUM(x, y []float64, sdev float64, minseg int) []int {
+	sig := make([]float64, len(y))
+	for i := range sig {
+		sig[i] = 1
+	}
+	c := newSegCost(x, y, sig)
+	var cps []int
+	var rec func(i, j int)
+	rec = func(i, j int) {
+		n := j - i
+		if n < 2*minseg {
+			return
+		}
+		a, b := c.fit(i, j)
+		s, best, arg := 0., 0., -1
+		for k := i; k < j-1; k++ {
+			s += y[k] - a
+			if x != nil {
+				s -= b * x[k]
+			}
+			if k+1-i >= minseg && j-k-1 >= minseg && math.Abs(s) > best {
+				best, arg = math.Abs(s), k+1
+			}
+		}
+		if arg < 0 || best/(sdev*math.Sqrt(float64(n))) <= cusumCrit {
+			return
+		}
+		cps = append(cps, arg)
+		rec(i, arg)
+		rec(arg, j)
+	}
+	rec(0, len(y))
+	sort.Ints(cps)
+	return cps
+}
+
+// ChangePoints returns the change points of y (indexes of the first point of each new segment) :
+// changes of the mean if x is nil, else changes of the straight line fitting y(x) (knees)
+// sig are the standard deviations of the points, if nil the noise is estimated with NoiseSdev
+// pen is the penalty per change point of PELT and BinSeg, if 0 the BIC penalty 2 ln(n) (3 ln(n) with x) is used
+// minseg is the minimum length of the segments
+func ChangePoints(x, y, sig []float64, m ChangeMethod, pen float64, minseg int) ([]int, error) {
+	n := len(y)
+	if minseg < 1 {
+		return nil, errors.New("ChangePoints: the minimum segment length must be positive")
+	}
+	if (sig != nil && len(sig) != n) || (x != nil && len(x) != n) {
+		return nil, errors.New("ChangePoints: x, y and sig must have the same length")
+	}
+	if n < 2*minseg {
+		return nil, nil
+	}
+	if sig == nil {
+		sdev := NoiseSdev(y)
+		if x != nil {
+			// the differences of a straight line are constant if the abscissa are evenly spaced
+			d := make([]float64, 0, n)
+			for i := 1; i < n; i++ {
+				d = append(d, y[i]-y[i-1])
+			}
+			sdev = NoiseSdev(d) / math.Sqrt(3)
+		}
+		if sdev == 0 {
+			if _, _, sdev, _, _, _ = Moments(y); sdev == 0 {
+				// constant data
+				return nil, nil
+			}
+		}
+		sig = make([]float64, n)
+		for i := range sig {
+			sig[i] = sdev
+		}
+	}
+	for _, s := range sig {
+		if s <= 0 {
+			return nil, errors.New("ChangePoints: the standard deviations must be positive")
+		}
+	}
+	if pen == 0 {
+		// one parameter per segment and the position of the change, plus the slope
+		pen = 2 * math.Log(float64(n))
+		if x != nil {
+			pen = 3 * math.Log(float64(n))
+		}
+	}
+	switch m {
+	case CpPELT:
+		return PELT(x, y, sig, pen, minseg), nil
+	case CpBinSeg:
+		return BinSeg(x, y, sig, pen, minseg), nil
+	case CpCUSUM:
+		// the CUSUM statistic of weighted data is not defined, use the mean noise
+		sdev := 0.
+		for _, s := range sig {
+			sdev += s * s
+		}
+		return CUSUM(x, y, math.Sqrt(sdev/float64(n)), minseg), nil
+	}
+	return nil, errors.New("ChangePoints: unknown method")
+}
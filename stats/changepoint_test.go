@@ -0,0 +1,117 @@
+package stats
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// Gaussian noise around the means of consecutive segments of the given lengths
+func steps(means []float64, lengths []int, seed int64) []float64 {
+	r := rand.New(rand.NewSource(seed))
+	var y []float64
+	for i, m := range means {
+		for j := 0; j < lengths[i]; j++ {
+			y = append(y, m+r.NormFloat64())
+		}
+	}
+	return y
+}
+
+func TestChangePoints(t *testing.T) {
+	y := steps([]float64{10, 14, 9}, []int{300, 200, 500}, 1)
+	wanted := []int{300, 500}
+	for _, m := range []ChangeMethod{CpPELT, CpBinSeg, CpCUSUM} {
+		cps, err := ChangePoints(nil, y, nil, m, 0, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(cps) != len(wanted) {
+			t.Fatalf("Bad change points with %s: wanted: %v found: %v", m, wanted, cps)
+		}
+		for i := range cps {
+			if cps[i] < wanted[i]-3 || cps[i] > wanted[i]+3 {
+				t.Errorf("Bad change points with %s: wanted: %v found: %v", m, wanted, cps)
+			}
+		}
+	}
+}
+
+// No change point in stationary noise
+func TestChangePointsNone(t *testing.T) {
+	y := steps([]float64{10}, []int{1000}, 2)
+	for _, m := range []ChangeMethod{CpPELT, CpBinSeg, CpCUSUM} {
+		cps, err := ChangePoints(nil, y, nil, m, 0, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(cps) != 0 {
+			t.Errorf("Bad change points with %s: wanted: [] found: %v", m, cps)
+		}
+	}
+}
+
+// A knee in a short sweep of means with known errors
+func TestChangePointsKnee(t *testing.T) {
+	y := []float64{6, 6.1, 6.05, 6.2, 9, 9.3, 9.1}
+	sig := []float64{0.1, 0.1, 0.1, 0.1, 0.2, 0.2, 0.2}
+	cps, err := ChangePoints(nil, y, sig, CpPELT, 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(cps, []int{4}) {
+		t.Errorf("Bad change points: wanted: [4] found: %v", cps)
+	}
+}
+
+// PELT is exact : its penalized cost is never higher than the one of the binary segmentation
+func TestPELTOptimal(t *testing.T) {
+	y := steps([]float64{0, 1, 0, 1.5, 0.5}, []int{50, 30, 80, 20, 60}, 3)
+	sig := make([]float64, len(y))
+	for i := range sig {
+		sig[i] = 1
+	}
+	pen := 10.
+	c := newSegCost(nil, y, sig)
+	total := func(cps []int) float64 {
+		bounds := append(append([]int{0}, cps...), len(y))
+		v := pen * float64(len(cps))
+		for i := 1; i < len(bounds); i++ {
+			v += c.cost(bounds[i-1], bounds[i])
+		}
+		return v
+	}
+	p, b := PELT(nil, y, sig, pen, 5), BinSeg(nil, y, sig, pen, 5)
+	if total(p) > total(b)+1e-9 {
+		t.Errorf("PELT not optimal: %v cost %f, binseg %v cost %f", p, total(p), b, total(b))
+	}
+}
+
+// A change of slope in a sweep with unevenly spaced abscissa
+func TestChangePointsSlope(t *testing.T) {
+	x := []float64{100, 300, 500, 700, 1000, 1500, 2000, 3000, 4000, 5000}
+	y := make([]float64, len(x))
+	sig := make([]float64, len(x))
+	r := rand.New(rand.NewSource(4))
+	for i := range x {
+		y[i] = 5 + 0.001*x[i] + 0.05*r.NormFloat64()
+		if x[i] > 1000 {
+			y[i] += 0.01 * (x[i] - 1000)
+		}
+		sig[i] = 0.05
+	}
+	for _, m := range []ChangeMethod{CpPELT, CpBinSeg, CpCUSUM} {
+		cps, err := ChangePoints(x, y, sig, m, 0, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := false
+		for _, c := range cps {
+			found = found || c == 4 || c == 5
+		}
+		// the CUSUM test is only asymptotic, it may split the short segments
+		if !found || (m != CpCUSUM && len(cps) != 1) {
+			t.Errorf("Bad change points with %s: wanted: [4] or [5] found: %v", m, cps)
+		}
+	}
+}
@@ -0,0 +1,697 @@
+package stats
+
+import (
+	"errors"
+	"math"
+	"plots/sliceutil"
+	"sort"
+)
+
+// Distribution is a continuous theoretical distribution fitted to a sample
+type Distribution interface {
+	Name() string               // name of the distribution
+	Pdf(x float64) float64      // probability density function
+	Cdf(x float64) float64      // cumulative distribution function
+	Quantile(p float64) float64 // inverse of the cumulative distribution function
+	NumParams() int             // number of fitted parameters
+}
+
+// Normal distribution of mean Mu and standard deviation Sigma
+type Normal struct {
+	Mu, Sigma float64
+}
+
+func (d Normal) Name() string {
+	return "normal"
+}
+
+func (d Normal) Pdf(x float64) float64 {
+	return Gauss(x, d.Mu, d.Sigma)
+}
+
+func (d Normal) Cdf(x float64) float64 {
+	return 0.5 * math.Erfc(-(x-d.Mu)/(d.Sigma*math.Sqrt2))
+}
+
+func (d Normal) Quantile(p float64) float64 {
+	return d.Mu + d.Sigma*math.Sqrt2*math.Erfinv(2.*p-1.)
+}
+
+func (d Normal) NumParams() int {
+	return 2
+}
+
+// LogNormal distribution : log(x) is normal of mean Mu and standard deviation Sigma
+type LogNormal struct {
+	Mu, Sigma float64
+}
+
+func (d LogNormal) Name() string {
+	return "log-normal"
+}
+
+func (d LogNormal) Pdf(x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	return Gauss(math.Log(x), d.Mu, d.Sigma) / x
+}
+
+func (d LogNormal) Cdf(x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	return Normal{d.Mu, d.Sigma}.Cdf(math.Log(x))
+}
+
+func (d LogNormal) Quantile(p float64) float64 {
+	return math.Exp(Normal{d.Mu, d.Sigma}.Quantile(p))
+}
+
+func (d LogNormal) NumParams() int {
+	return 2
+}
+
+// Exponential distribution of rate Lambda
+type Exponential struct {
+	Lambda float64
+}
+
+func (d Exponential) Name() string {
+	return "exponential"
+}
+
+func (d Exponential) Pdf(x float64) float64 {
+	if x < 0 {
+		return 0
+	}
+	return d.Lambda * math.Exp(-d.Lambda*x)
+}
+
+func (d Exponential) Cdf(x float64) float64 {
+	if x < 0 {
+		return 0
+	}
+	return -math.Expm1(-d.Lambda * x)
+}
+
+func (d Exponential) Quantile(p float64) float64 {
+	return -math.Log1p(-p) / d.Lambda
+}
+
+func (d Exponential) NumParams() int {
+	return 1
+}
+
+// Gamma distribution of shape K and scale Theta
+type Gamma struct {
+	K, Theta float64
+}
+
+func (d Gamma) Name() string {
+	return "gamma"
+}
+
+func (d Gamma) Pdf(x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	lg, _ := math.Lgamma(d.K)
+	return math.Exp((d.K-1)*math.Log(x) - x/d.Theta - lg - d.K*math.Log(d.Theta))
+}
+
+func (d Gamma) Cdf(x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	return Gammp(d.K, x/d.Theta)
+}
+
+func (d Gamma) Quantile(p float64) float64 {
+	return invertCdf(d, p, d.K*d.Theta, math.Sqrt(d.K)*d.Theta, 0)
+}
+
+func (d Gamma) NumParams() int {
+	return 2
+}
+
+// Find x such that d.Cdf(x) = p by bisection
+// start, step : initial guess and step used to bracket the root
+// lower : lower bound of the support of the distribution
+func invertCdf(d Distribution, p, start, step, lower float64) float64 {
+	if p <= 0 {
+		return lower
+	}
+	if p >= 1 {
+		return math.Inf(1)
+	}
+	lo, hi := start, start
+	for d.Cdf(lo) > p {
+		lo -= step
+		step *= 2
+		if lo <= lower {
+			lo = lower
+			break
+		}
+	}
+	for d.Cdf(hi) < p {
+		hi += step
+		step *= 2
+	}
+	for i := 0; i < 100 && hi-lo > 1e-12*math.Abs(hi); i++ {
+		mid := (lo + hi) / 2.
+		if d.Cdf(mid) < p {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2.
+}
+
+// Gammp returns the regularized lower incomplete gamma function P(a, x)
+func Gammp(a, x float64) float64 {
+	if x <= 0 || a <= 0 {
+		return 0
+	}
+	if x < a+1 {
+		return gser(a, x)
+	}
+	return 1. - gcf(a, x)
+}
+
+// Series representation of the incomplete gamma function P(a, x)
+func gser(a, x float64) float64 {
+	gln, _ := math.Lgamma(a)
+	ap := a
+	sum := 1. / a
+	del := sum
+	for n := 0; n < 1000; n++ {
+		ap++
+		del *= x / ap
+		sum += del
+		if math.Abs(del) < math.Abs(sum)*1e-15 {
+			break
+		}
+	}
+	return sum * math.Exp(-x+a*math.Log(x)-gln)
+}
+
+// Continued fraction representation of the incomplete gamma function Q(a, x) (modified Lentz's method)
+func gcf(a, x float64) float64 {
+	const fpmin = 1e-300
+	gln, _ := math.Lgamma(a)
+	b := x + 1. - a
+	c := 1. / fpmin
+	d := 1. / b
+	h := d
+	for i := 1; i < 1000; i++ {
+		an := -float64(i) * (float64(i) - a)
+		b += 2.
+		d = an*d + b
+		if math.Abs(d) < fpmin {
+			d = fpmin
+		}
+		c = b + an/c
+		if math.Abs(c) < fpmin {
+			c = fpmin
+		}
+		d = 1. / d
+		del := d * c
+		h *= del
+		if math.Abs(del-1.) < 1e-15 {
+			break
+		}
+	}
+	return math.Exp(-x+a*math.Log(x)-gln) * h
+}
+
+// FitNormal returns the normal distribution fitted to the data by maximum likelihood
+// the mean and the standard deviation of the data normalized by n (not n - 1)
+func FitNormal(data []float64) (Normal, error) {
+	mean, _, sdev, _, _, err := Moments(data)
+	if err != nil {
+		return Normal{}, err
+	}
+	n := float64(len(data))
+	return Normal{Mu: mean, Sigma: sdev * math.Sqrt((n-1.)/n)}, nil
+}
+
+// FitLogNormal returns the log-normal distribution fitted to the data (all > 0)
+func FitLogNormal(data []float64) (LogNormal, error) {
+	logs := make([]float64, len(data))
+	for i, d := range data {
+		if d <= 0 {
+			return LogNormal{}, errors.New("FitLogNormal: data must be positive")
+		}
+		logs[i] = math.Log(d)
+	}
+	n, err := FitNormal(logs)
+	if err != nil {
+		return LogNormal{}, err
+	}
+	return LogNormal{Mu: n.Mu, Sigma: n.Sigma}, nil
+}
+
+// FitExponential returns the exponential distribution fitted to the data (all >= 0)
+func FitExponential(data []float64) (Exponential, error) {
+	if len(data) == 0 {
+		return Exponential{}, errors.New("FitExponential: no data")
+	}
+	s := 0.
+	for _, d := range data {
+		if d < 0 {
+			return Exponential{}, errors.New("FitExponential: data must be positive")
+		}
+		s += d
+	}
+	if s == 0 {
+		return Exponential{}, errors.New("FitExponential: null mean")
+	}
+	return Exponential{Lambda: float64(len(data)) / s}, nil
+}
+
+// FitGamma returns the gamma distribution fitted to the data (all > 0) by maximum likelihood
+// the shape k solves ln(k) - digamma(k) = ln(mean) - mean(ln(x)) (Newton's method)
+func FitGamma(data []float64) (Gamma, error) {
+	if len(data) < 2 {
+		return Gamma{}, errors.New("FitGamma: n must be at least 2")
+	}
+	mean, lmean := 0., 0.
+	for _, d := range data {
+		if d <= 0 {
+			return Gamma{}, errors.New("FitGamma: data must be positive")
+		}
+		mean += d
+		lmean += math.Log(d)
+	}
+	an := float64(len(data))
+	mean /= an
+	lmean /= an
+	s := math.Log(mean) - lmean
+	if s <= 0 {
+		return Gamma{}, errors.New("FitGamma: no variance")
+	}
+	k := (3. - s + math.Sqrt((s-3.)*(s-3.)+24.*s)) / (12. * s)
+	for i := 0; i < 100; i++ {
+		dk := (math.Log(k) - Digamma(k) - s) / (1./k - Trigamma(k))
+		k -= dk
+		if k <= 0 {
+			k = (k + dk) / 2.
+		}
+		if math.Abs(dk) < 1e-12*k {
+			break
+		}
+	}
+	return Gamma{K: k, Theta: mean / k}, nil
+}
+
+// Weibull distribution of shape K and scale Lambda
+type Weibull struct {
+	K, Lambda float64
+}
+
+func (d Weibull) Name() string {
+	return "weibull"
+}
+
+func (d Weibull) Pdf(x float64) float64 {
+	if x < 0 {
+		return 0
+	}
+	z := x / d.Lambda
+	return d.K / d.Lambda * math.Pow(z, d.K-1) * math.Exp(-math.Pow(z, d.K))
+}
+
+func (d Weibull) Cdf(x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	return -math.Expm1(-math.Pow(x/d.Lambda, d.K))
+}
+
+func (d Weibull) Quantile(p float64) float64 {
+	return d.Lambda * math.Pow(-math.Log1p(-p), 1./d.K)
+}
+
+func (d Weibull) NumParams() int {
+	return 2
+}
+
+// FitWeibull returns the Weibull distribution fitted to the data (all > 0) by maximum likelihood
+// the shape k solves sum(x^k ln(x)) / sum(x^k) - 1/k - mean(ln(x)) = 0 (Newton's method)
+func FitWeibull(data []float64) (Weibull, error) {
+	if len(data) < 2 {
+		return Weibull{}, errors.New("FitWeibull: n must be at least 2")
+	}
+	// work on x / max to avoid overflows
+	_, max := sliceutil.MinMax(data)
+	logs := make([]float64, len(data))
+	for i, d := range data {
+		if d <= 0 {
+			return Weibull{}, errors.New("FitWeibull: data must be positive")
+		}
+		logs[i] = math.Log(d / max)
+	}
+	lmean, _, lsdev, _, _, err := Moments(logs)
+	if err != nil {
+		return Weibull{}, err
+	}
+	k := 1.2825 / lsdev
+	for i := 0; i < 100; i++ {
+		s0, s1, s2 := 0., 0., 0.
+		for _, l := range logs {
+			xk := math.Exp(k * l)
+			s0 += xk
+			s1 += xk * l
+			s2 += xk * l * l
+		}
+		g := s1/s0 - 1./k - lmean
+		dg := (s2*s0-s1*s1)/(s0*s0) + 1./(k*k)
+		dk := g / dg
+		k -= dk
+		if k <= 0 {
+			k = (k + dk) / 2.
+		}
+		if math.Abs(dk) < 1e-12*k {
+			break
+		}
+	}
+	s0 := 0.
+	for _, l := range logs {
+		s0 += math.Exp(k * l)
+	}
+	return Weibull{K: k, Lambda: max * math.Pow(s0/float64(len(logs)), 1./k)}, nil
+}
+
+// Shifted is the distribution Dist translated by Loc : X = Loc + Y where Y follows Dist
+type Shifted struct {
+	Dist Distribution
+	Loc  float64
+}
+
+func (d Shifted) Name() string {
+	return "shifted " + d.Dist.Name()
+}
+
+func (d Shifted) Pdf(x float64) float64 {
+	return d.Dist.Pdf(x - d.Loc)
+}
+
+func (d Shifted) Cdf(x float64) float64 {
+	return d.Dist.Cdf(x - d.Loc)
+}
+
+func (d Shifted) Quantile(p float64) float64 {
+	return d.Loc + d.Dist.Quantile(p)
+}
+
+func (d Shifted) NumParams() int {
+	return d.Dist.NumParams() + 1
+}
+
+// FitShifted fits the distribution returned by "fit" to data - loc
+// the location loc in ]min - (max - min), min[ maximizes the likelihood (golden section search)
+func FitShifted(data []float64, fit func([]float64) (Distribution, error)) (Shifted, error) {
+	min, max := sliceutil.MinMax(data)
+	if max == min {
+		return Shifted{}, errors.New("FitShifted: no variance")
+	}
+	shifted := make([]float64, len(data))
+	// - log likelihood of the fit with location loc
+	nll := func(loc float64) float64 {
+		for i, d := range data {
+			shifted[i] = d - loc
+		}
+		d, err := fit(shifted)
+		if err != nil {
+			return math.Inf(1)
+		}
+		return -LogLikelihood(Shifted{Dist: d, Loc: loc}, data)
+	}
+	const r = 0.6180339887498949
+	a, b := min-(max-min), min-(max-min)*1e-6
+	c, d := b-r*(b-a), a+r*(b-a)
+	fc, fd := nll(c), nll(d)
+	for i := 0; i < 60; i++ {
+		if fc < fd {
+			b, d, fd = d, c, fc
+			c = b - r*(b-a)
+			fc = nll(c)
+		} else {
+			a, c, fc = c, d, fd
+			d = a + r*(b-a)
+			fd = nll(d)
+		}
+	}
+	loc := (a + b) / 2.
+	for i, v := range data {
+		shifted[i] = v - loc
+	}
+	dist, err := fit(shifted)
+	if err != nil {
+		return Shifted{}, err
+	}
+	return Shifted{Dist: dist, Loc: loc}, nil
+}
+
+// Digamma returns the logarithmic derivative of the gamma function
+func Digamma(x float64) float64 {
+	res := 0.
+	// recurrence to get a large argument
+	for ; x < 6; x++ {
+		res -= 1. / x
+	}
+	// asymptotic expansion
+	f := 1. / (x * x)
+	return res + math.Log(x) - 0.5/x - f*(1./12-f*(1./120-f*(1./252-f*(1./240-f/132))))
+}
+
+// Trigamma returns the derivative of the digamma function
+func Trigamma(x float64) float64 {
+	res := 0.
+	// recurrence to get a large argument
+	for ; x < 6; x++ {
+		res += 1. / (x * x)
+	}
+	// asymptotic expansion
+	f := 1. / (x * x)
+	return res + 1./x + f/2. + f/x*(1./6-f*(1./30-f*(1./42-f/30)))
+}
+
+// FitDistributions fits the normal, log-normal, gamma and exponential distributions to the data by maximum likelihood
+// the distributions that cannot be fitted (eg. log-normal with negative data) are skipped
+func FitDistributions(data []float64) []Distribution {
+	var dists []Distribution
+	if d, err := FitNormal(data); err == nil {
+		dists = append(dists, d)
+	}
+	if d, err := FitLogNormal(data); err == nil {
+		dists = append(dists, d)
+	}
+	if d, err := FitGamma(data); err == nil {
+		dists = append(dists, d)
+	}
+	if d, err := FitExponential(data); err == nil {
+		dists = append(dists, d)
+	}
+	return dists
+}
+
+// LogLikelihood returns the log of the likelihood of the data for the distribution d
+func LogLikelihood(d Distribution, data []float64) float64 {
+	ll := 0.
+	for _, x := range data {
+		ll += math.Log(d.Pdf(x))
+	}
+	return ll
+}
+
+// FitResult holds a fitted distribution and its goodness-of-fit statistics
+type FitResult struct {
+	Dist   Distribution
+	LogLik float64 // log likelihood
+	AIC    float64 // Akaike information criterion 2k - 2 LogLik
+	BIC    float64 // Bayesian information criterion k ln(n) - 2 LogLik
+	KS     float64 // Kolmogorov-Smirnov statistic
+	AD     float64 // Anderson-Darling statistic
+}
+
+// GoodnessOfFit computes the goodness-of-fit statistics of the distribution d for the data
+func GoodnessOfFit(d Distribution, data []float64) FitResult {
+	n := len(data)
+	an := float64(n)
+	k := float64(d.NumParams())
+	sorted := make([]float64, n)
+	copy(sorted, data)
+	sort.Float64s(sorted)
+	ll := LogLikelihood(d, sorted)
+	ks, ad := 0., 0.
+	cdf := make([]float64, n)
+	for i, x := range sorted {
+		// keep log(F) and log(1-F) finite
+		cdf[i] = math.Min(math.Max(d.Cdf(x), 1e-300), 1.-1e-16)
+		ks = math.Max(ks, math.Max(float64(i+1)/an-cdf[i], cdf[i]-float64(i)/an))
+	}
+	for i := range cdf {
+		ad += float64(2*i+1) * (math.Log(cdf[i]) + math.Log1p(-cdf[n-1-i]))
+	}
+	return FitResult{
+		Dist:   d,
+		LogLik: ll,
+		AIC:    2.*k - 2.*ll,
+		BIC:    k*math.Log(an) - 2.*ll,
+		KS:     ks,
+		AD:     -an - ad/an,
+	}
+}
+
+// FitAll fits by maximum likelihood the normal, log-normal, gamma, Weibull, exponential distributions
+// and the shifted log-normal, gamma, Weibull and exponential distributions to the data
+// Returns the fits that succeeded ranked by increasing AIC (best first)
+func FitAll(data []float64) []FitResult {
+	dists := FitDistributions(data)
+	if d, err := FitWeibull(data); err == nil {
+		dists = append(dists, d)
+	}
+	fits := []func([]float64) (Distribution, error){
+		func(x []float64) (Distribution, error) { return FitLogNormal(x) },
+		func(x []float64) (Distribution, error) { return FitGamma(x) },
+		func(x []float64) (Distribution, error) { return FitWeibull(x) },
+		func(x []float64) (Distribution, error) { return FitExponential(x) },
+	}
+	for _, fit := range fits {
+		if d, err := FitShifted(data, fit); err == nil {
+			dists = append(dists, d)
+		}
+	}
+	res := make([]FitResult, 0, len(dists))
+	for _, d := range dists {
+		r := GoodnessOfFit(d, data)
+		if math.IsNaN(r.AIC) || math.IsInf(r.AIC, 0) {
+			continue
+		}
+		res = append(res, r)
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].AIC < res[j].AIC
+	})
+	return res
+}
+
+// Betai returns the regularized incomplete beta function I_x(a, b)
+func Betai(a, b, x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+	la, _ := math.Lgamma(a)
+	lb, _ := math.Lgamma(b)
+	lab, _ := math.Lgamma(a + b)
+	bt := math.Exp(lab - la - lb + a*math.Log(x) + b*math.Log1p(-x))
+	// Use the continued fraction directly or after the symmetry transformation
+	if x < (a+1.)/(a+b+2.) {
+		return bt * betacf(a, b, x) / a
+	}
+	return 1. - bt*betacf(b, a, 1.-x)/b
+}
+
+// Continued fraction for the incomplete beta function (modified Lentz's method)
+func betacf(a, b, x float64) float64 {
+	const fpmin = 1e-300
+	qab, qap, qam := a+b, a+1., a-1.
+	c := 1.
+	d := 1. - qab*x/qap
+	if math.Abs(d) < fpmin {
+		d = fpmin
+	}
+	d = 1. / d
+	h := d
+	for m := 1; m < 1000; m++ {
+		fm := float64(m)
+		m2 := 2. * fm
+		aa := fm * (b - fm) * x / ((qam + m2) * (a + m2))
+		// Even step of the recurrence
+		d = 1. + aa*d
+		if math.Abs(d) < fpmin {
+			d = fpmin
+		}
+		c = 1. + aa/c
+		if math.Abs(c) < fpmin {
+			c = fpmin
+		}
+		d = 1. / d
+		h *= d * c
+		aa = -(a + fm) * (qab + fm) * x / ((a + m2) * (qap + m2))
+		// Odd step of the recurrence
+		d = 1. + aa*d
+		if math.Abs(d) < fpmin {
+			d = fpmin
+		}
+		c = 1. + aa/c
+		if math.Abs(c) < fpmin {
+			c = fpmin
+		}
+		d = 1. / d
+		del := d * c
+		h *= del
+		if math.Abs(del-1.) < 1e-15 {
+			break
+		}
+	}
+	return h
+}
+
+// StudentTCdf returns the cumulative distribution function of the Student's t distribution with nu degrees of freedom
+func StudentTCdf(t, nu float64) float64 {
+	p := 0.5 * Betai(nu/2., 0.5, nu/(nu+t*t))
+	if t > 0 {
+		return 1. - p
+	}
+	return p
+}
+
+// StudentTQuantile returns the quantile p of the Student's t distribution with nu degrees of freedom
+func StudentTQuantile(p, nu float64) float64 {
+	if p <= 0 {
+		return math.Inf(-1)
+	}
+	if p >= 1 {
+		return math.Inf(1)
+	}
+	lo, hi := -1., 1.
+	for StudentTCdf(lo, nu) > p {
+		lo *= 2
+	}
+	for StudentTCdf(hi, nu) < p {
+		hi *= 2
+	}
+	for i := 0; i < 200 && hi-lo > 1e-12*(1+math.Abs(hi)); i++ {
+		mid := (lo + hi) / 2.
+		if StudentTCdf(mid, nu) < p {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2.
+}
+
+// Welch returns the t statistic, the degrees of freedom and the one-sided p-value p(T >= t)
+// of Welch's test of the hypothesis mean2 > mean1, the samples having the given means, standard deviations and sizes
+// the sizes of correlated samples are their effective sample sizes
+func Welch(mean1, sdev1, n1, mean2, sdev2, n2 float64) (float64, float64, float64, error) {
+	if n1 < 2 || n2 < 2 {
+		return 0, 0, 0, errors.New("Welch: need at least 2 values per sample")
+	}
+	v1 := sdev1 * sdev1 / n1
+	v2 := sdev2 * sdev2 / n2
+	if v1+v2 == 0 {
+		return 0, 0, 0, errors.New("Welch: null variances")
+	}
+	t := (mean2 - mean1) / math.Sqrt(v1+v2)
+	dof := (v1 + v2) * (v1 + v2) / (v1*v1/(n1-1.) + v2*v2/(n2-1.))
+	return t, dof, 1. - StudentTCdf(t, dof), nil
+}
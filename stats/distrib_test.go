@@ -0,0 +1,120 @@
+package stats
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// Check that Quantile is the inverse of Cdf
+func quantile_innertest(d Distribution, t *testing.T) {
+	for _, p := range []float64{0.001, 0.1, 0.5, 0.9, 0.999} {
+		x := d.Quantile(p)
+		if math.Abs(d.Cdf(x)-p) > 1e-6 {
+			t.Errorf("Bad %s quantile: wanted: cdf(%f)=%f found: %f", d.Name(), x, p, d.Cdf(x))
+		}
+	}
+}
+
+func TestQuantileNormal(t *testing.T) {
+	quantile_innertest(Normal{Mu: 5, Sigma: 2}, t)
+}
+
+func TestQuantileLogNormal(t *testing.T) {
+	quantile_innertest(LogNormal{Mu: 1, Sigma: 0.5}, t)
+}
+
+func TestQuantileExponential(t *testing.T) {
+	quantile_innertest(Exponential{Lambda: 0.2}, t)
+}
+
+func TestQuantileGamma(t *testing.T) {
+	quantile_innertest(Gamma{K: 0.5, Theta: 3}, t)
+	quantile_innertest(Gamma{K: 9, Theta: 0.5}, t)
+}
+
+// P(1, x) = 1 - exp(-x)
+func TestGammp(t *testing.T) {
+	for _, x := range []float64{0.1, 1, 5, 20} {
+		if math.Abs(Gammp(1, x)+math.Expm1(-x)) > 1e-12 {
+			t.Errorf("Bad Gammp(1, %f): wanted: %f found: %f", x, -math.Expm1(-x), Gammp(1, x))
+		}
+	}
+}
+
+// Exponential samples are gamma and Weibull distributed with a shape of 1
+func TestFitGammaWeibull(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	data := make([]float64, 20000)
+	for i := range data {
+		data[i] = 3. * r.ExpFloat64()
+	}
+	g, err := FitGamma(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(g.K-1) > 0.05 || math.Abs(g.Theta-3) > 0.1 {
+		t.Errorf("Bad gamma fit: wanted: k=1 theta=3 found: k=%f theta=%f", g.K, g.Theta)
+	}
+	w, err := FitWeibull(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(w.K-1) > 0.05 || math.Abs(w.Lambda-3) > 0.1 {
+		t.Errorf("Bad Weibull fit: wanted: k=1 lambda=3 found: k=%f lambda=%f", w.K, w.Lambda)
+	}
+}
+
+// The maximum likelihood standard deviation is normalized by n
+func TestFitNormal(t *testing.T) {
+	d, err := FitNormal([]float64{1, 2, 3, 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Mu != 2.5 || math.Abs(d.Sigma-math.Sqrt(1.25)) > 1e-12 {
+		t.Errorf("Bad normal fit: wanted: mu=2.5 sigma=%f found: mu=%f sigma=%f", math.Sqrt(1.25), d.Mu, d.Sigma)
+	}
+}
+
+// The shifted log-normal should be found best for shifted log-normal samples
+func TestFitAll(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	data := make([]float64, 5000)
+	for i := range data {
+		data[i] = 10. + math.Exp(1.+0.5*r.NormFloat64())
+	}
+	fits := FitAll(data)
+	if len(fits) == 0 {
+		t.Fatal("No fit found")
+	}
+	best, ok := fits[0].Dist.(Shifted)
+	if !ok || best.Dist.Name() != "log-normal" {
+		t.Fatalf("Bad best fit: wanted: shifted log-normal found: %s", fits[0].Dist.Name())
+	}
+	if math.Abs(best.Loc-10) > 0.5 {
+		t.Errorf("Bad location: wanted: 10 found: %f", best.Loc)
+	}
+	if fits[0].KS > 0.02 {
+		t.Errorf("Too big KS statistic: %f", fits[0].KS)
+	}
+}
+
+// Known quantiles of the Student's t distribution
+func TestStudentTQuantile(t *testing.T) {
+	for _, c := range []struct{ p, nu, t float64 }{{0.975, 1, 12.7062}, {0.975, 10, 2.2281}, {0.95, 5, 2.0150}, {0.5, 3, 0}} {
+		if q := StudentTQuantile(c.p, c.nu); math.Abs(q-c.t) > 1e-3 {
+			t.Errorf("Bad t quantile(%f, %f): wanted: %f found: %f", c.p, c.nu, c.t, q)
+		}
+	}
+}
+
+// t = 1 / sqrt(4/30 + 9/40), Welch-Satterthwaite degrees of freedom
+func TestWelch(t *testing.T) {
+	tt, dof, p, err := Welch(10, 2, 30, 11, 3, 40)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(tt-1.67054) > 1e-4 || math.Abs(dof-67.188) > 1e-3 || math.Abs(p-0.0497) > 5e-4 {
+		t.Errorf("Bad Welch test: wanted: t=1.67054 dof=67.188 p=0.0497 found: t=%f dof=%f p=%f", tt, dof, p)
+	}
+}
@@ -0,0 +1,186 @@
+package stats
+
+import (
+	"math"
+	"sort"
+)
+
+// Return the robust spread min(sdev, IQR/1.34) of the data, 0 if they are constant
+func spread(data []float64) float64 {
+	_, _, sdev, _, _, err := Moments(data)
+	if err != nil {
+		sdev = 0
+	}
+	sorted := make([]float64, len(data))
+	copy(sorted, data)
+	sort.Float64s(sorted)
+	iqr := (quantileSorted(sorted, 0.75) - quantileSorted(sorted, 0.25)) / 1.34
+	if iqr > 0 && iqr < sdev {
+		return iqr
+	}
+	return sdev
+}
+
+// Silverman returns the rule-of-thumb bandwidth of a gaussian kernel density estimation
+// h = 0.9 min(sdev, IQR/1.34) n^(-1/5)
+func Silverman(data []float64) float64 {
+	n := len(data)
+	if n < 2 {
+		return 1.
+	}
+	s := spread(data)
+	if s == 0 {
+		return 1.
+	}
+	return 0.9 * s * math.Pow(float64(n), -0.2)
+}
+
+// Kernel of the density estimations
+type Kernel int
+
+const (
+	KGauss        Kernel = iota // standard normal density
+	KEpanechnikov               // 3 / (4 sqrt(5)) (1 - u^2 / 5) on [-sqrt(5), sqrt(5)], scaled to a unit variance
+)
+
+func (k Kernel) String() string {
+	return [...]string{"gauss", "epanechnikov"}[k]
+}
+
+// Both kernels have a unit variance, so that the bandwidths are the standard deviations of the kernels
+// and the same rules apply (the optimal bandwidths differ by 1%)
+var sqrt5 = math.Sqrt(5.)
+
+// eval returns the kernel at u
+func (k Kernel) eval(u float64) float64 {
+	if k == KEpanechnikov {
+		if u <= -sqrt5 || u >= sqrt5 {
+			return 0
+		}
+		return 0.75 / sqrt5 * (1. - u*u/5.)
+	}
+	return math.Exp(-0.5*u*u) / math.Sqrt(2.*math.Pi)
+}
+
+// KDE returns the gaussian kernel density estimation of the data with bandwidth bw evaluated at x[0..m-1]
+func KDE(data []float64, bw float64, x []float64) []float64 {
+	return KernelDensity(data, bw, x, KGauss)
+}
+
+// KernelDensity returns the kernel density estimation of the data with the kernel k and bandwidth bw
+// evaluated at x[0..m-1]
+func KernelDensity(data []float64, bw float64, x []float64, k Kernel) []float64 {
+	dens := make([]float64, len(x))
+	norm := 1. / (float64(len(data)) * bw)
+	for i, xx := range x {
+		s := 0.
+		for _, d := range data {
+			s += k.eval((xx - d) / bw)
+		}
+		dens[i] = s * norm
+	}
+	return dens
+}
+
+// Number of bins of the Sheather-Jones bandwidth
+const sjBins = 1000
+
+// Binned pair counts of the data : cnt[k] is the number of pairs of points distant of k bins of width d
+func sjPairs(data []float64) ([]float64, float64) {
+	min, max := data[0], data[0]
+	for _, v := range data {
+		min = math.Min(min, v)
+		max = math.Max(max, v)
+	}
+	d := (max - min) * 1.01 / sjBins
+	counts := make([]float64, sjBins)
+	for _, v := range data {
+		counts[int((v-min)/d)]++
+	}
+	cnt := make([]float64, sjBins)
+	for i, ci := range counts {
+		if ci == 0 {
+			continue
+		}
+		cnt[0] += ci * (ci - 1) / 2
+		for j := i + 1; j < sjBins; j++ {
+			cnt[j-i] += ci * counts[j]
+		}
+	}
+	return cnt, d
+}
+
+// Estimations of the integrals of the squared 2nd and 3rd derivatives of the density
+// with a gaussian kernel of bandwidth h on the binned pair counts
+func sjPhi(n int, d float64, cnt []float64, h float64, sixth bool) float64 {
+	sum := 0.
+	for i, c := range cnt {
+		delta := float64(i) * d / h
+		delta *= delta
+		if delta >= 1000 {
+			break
+		}
+		if sixth {
+			sum += c * math.Exp(-delta/2) * (delta*delta*delta - 15*delta*delta + 45*delta - 15)
+		} else {
+			sum += c * math.Exp(-delta/2) * (delta*delta - 6*delta + 3)
+		}
+	}
+	nn := float64(n)
+	if sixth {
+		// add the diagonal
+		sum = 2*sum - 15*nn
+		return sum / (nn * (nn - 1) * math.Pow(h, 7) * math.Sqrt(2*math.Pi))
+	}
+	sum = 2*sum + 3*nn
+	return sum / (nn * (nn - 1) * math.Pow(h, 5) * math.Sqrt(2*math.Pi))
+}
+
+// SheatherJones returns the "solve-the-equation" plug-in bandwidth of a gaussian kernel density estimation
+// (Sheather and Jones 1991), the data being binned in 1000 bins, or the Silverman bandwidth if it fails
+func SheatherJones(data []float64) float64 {
+	n := len(data)
+	hs := Silverman(data)
+	scale := spread(data)
+	if n < 3 || scale == 0 {
+		return hs
+	}
+	cnt, d := sjPairs(data)
+	nn := float64(n)
+	a := 1.24 * scale * math.Pow(nn, -1./7.)
+	b := 1.23 * scale * math.Pow(nn, -1./9.)
+	c1 := 1. / (2 * math.Sqrt(math.Pi) * nn)
+	td := -sjPhi(n, d, cnt, b, true)
+	if td <= 0 {
+		return hs
+	}
+	alph2 := 1.357 * math.Pow(sjPhi(n, d, cnt, a, false)/td, 1./7.)
+	f := func(h float64) float64 {
+		sd := sjPhi(n, d, cnt, alph2*math.Pow(h, 5./7.), false)
+		if sd <= 0 {
+			return math.NaN()
+		}
+		return math.Pow(c1/sd, 0.2) - h
+	}
+	// bracket the root around the normal reference bandwidth
+	hmax := 1.144 * scale * math.Pow(nn, -0.2)
+	lo, hi := 0.1*hmax, hmax
+	flo, fhi := f(lo), f(hi)
+	for i := 0; flo*fhi > 0 || math.IsNaN(flo*fhi); i++ {
+		if i == 100 {
+			return hs
+		}
+		lo, hi = lo/1.2, hi*1.2
+		flo, fhi = f(lo), f(hi)
+	}
+	// bisection
+	for hi-lo > 1e-6*hmax {
+		mid := (lo + hi) / 2
+		if fm := f(mid); fm*flo > 0 {
+			lo, flo = mid, fm
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2
+}
@@ -0,0 +1,109 @@
+package stats
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func normalData(n int, seed int64) []float64 {
+	r := rand.New(rand.NewSource(seed))
+	data := make([]float64, n)
+	for i := range data {
+		data[i] = r.NormFloat64()
+	}
+	return data
+}
+
+// The kernel density estimation integrates to 1
+func TestKDE(t *testing.T) {
+	data := normalData(500, 1)
+	x := make([]float64, 1601)
+	for i := range x {
+		x[i] = -8 + float64(i)/100
+	}
+	dens := KDE(data, 0.3, x)
+	sum := 0.
+	for i := 1; i < len(x); i++ {
+		sum += (dens[i] + dens[i-1]) / 2 * (x[i] - x[i-1])
+	}
+	if math.Abs(sum-1) > 1e-3 {
+		t.Errorf("Bad integral: wanted: 1 found: %f", sum)
+	}
+}
+
+// For normal data the Silverman bandwidth is 0.9 sdev n^(-1/5)
+func TestSilverman(t *testing.T) {
+	n := 2000
+	data := normalData(n, 2)
+	wanted := 0.9 * math.Pow(float64(n), -0.2)
+	if h := Silverman(data); math.Abs(h-wanted) > 0.05*wanted {
+		t.Errorf("Bad bandwidth: wanted: %f found: %f", wanted, h)
+	}
+	if h := Silverman([]float64{3}); h != 1 {
+		t.Errorf("Bad bandwidth of a single value: wanted: 1 found: %f", h)
+	}
+}
+
+// The kernel density estimations integrate to 1
+func TestKernelDensity(t *testing.T) {
+	data := normalData(500, 1)
+	x, _ := LinearBins(-8, 8, 1600)
+	for _, k := range []Kernel{KGauss, KEpanechnikov} {
+		dens := KernelDensity(data, 0.3, x, k)
+		sum := 0.
+		for i := 1; i < len(x); i++ {
+			sum += (dens[i] + dens[i-1]) / 2 * (x[i] - x[i-1])
+		}
+		if math.Abs(sum-1) > 1e-3 {
+			t.Errorf("Bad integral with %s: wanted: 1 found: %f", k, sum)
+		}
+	}
+}
+
+// For normal data the Sheather-Jones bandwidth is close to the optimal 1.06 sdev n^(-1/5)
+// and for bimodal data it is smaller than the Silverman rule of thumb
+func TestSheatherJones(t *testing.T) {
+	n := 2000
+	data := normalData(n, 2)
+	wanted := 1.06 * math.Pow(float64(n), -0.2)
+	if h := SheatherJones(data); math.Abs(h-wanted) > 0.15*wanted {
+		t.Errorf("Bad bandwidth: wanted: %f found: %f", wanted, h)
+	}
+	for i := range data[:n/2] {
+		data[i] += 6
+	}
+	if h, hs := SheatherJones(data), Silverman(data); h >= hs {
+		t.Errorf("Bad bimodal bandwidth: %f not lower than Silverman %f", h, hs)
+	}
+}
+
+func TestNumBins(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	data := make([]float64, 1000)
+	for i := range data {
+		data[i] = r.Float64()
+	}
+	// iqr = 0.5, width = 2 0.5 / 10
+	if nb := NumBins(data, BinFD); nb < 9 || nb > 11 {
+		t.Errorf("Bad Freedman-Diaconis bins: wanted: 10 found: %d", nb)
+	}
+	// sdev = 0.289, width = 3.49 0.289 / 10
+	if nb := NumBins(data, BinScott); nb < 9 || nb > 11 {
+		t.Errorf("Bad Scott bins: wanted: 10 found: %d", nb)
+	}
+	if nb := NumBins(data, BinSturges); nb != 11 {
+		t.Errorf("Bad Sturges bins: wanted: 11 found: %d", nb)
+	}
+}
+
+func TestToHistoEdges(t *testing.T) {
+	data := []float64{0.5, 1.5, 1.5, 3, 10}
+	h := ToHistoEdges(data, []float64{0, 1, 2, 4})
+	wanted := []float64{0.2, 0.4, 0.1}
+	for i := range wanted {
+		if math.Abs(h[i]-wanted[i]) > 1e-12 {
+			t.Errorf("Bad histogram: wanted: %v found: %v", wanted, h)
+		}
+	}
+}
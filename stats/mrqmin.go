@@ -0,0 +1,139 @@
+package stats
+
+import (
+	"errors"
+	"math"
+)
+
+// A model y(x; a) that depends nonlinearly on the parameters a[0..ma-1]
+// returns y and its derivatives dy/da[0..ma-1] at x
+type Fmodel func(x float64, a []float64) (float64, []float64)
+
+// FSaturation is the saturation curve y = a0 (1 - exp(-x / a1))
+// To be used with Mrqmin
+func FSaturation(x float64, a []float64) (float64, []float64) {
+	e := math.Exp(-x / a[1])
+	return a[0] * (1. - e), []float64{1. - e, -a[0] * e * x / (a[1] * a[1])}
+}
+
+// FInverse is the curve y = a0 + a1 / x
+// To be used with Mrqmin
+func FInverse(x float64, a []float64) (float64, []float64) {
+	return a[0] + a[1]/x, []float64{1., 1. / x}
+}
+
+// Levenberg-Marquardt method : given a set of data points x[0..ndat-1], y[0..ndat-1] with individual standard deviations sig[0..ndat-1],
+// use chi2 minimization to fit for some or all of the coefficients a[0..ma-1] of a function that depends nonlinearly on a, y = funcs(x, a)
+// The input array ia[0..ma-1] indicates those components of a that should be fitted for (others are held fixed)
+// On input a holds the initial guesses, on output the fitted values
+// the program returns chi2 and the covariance matrix covar[0..ma-1][0..ma-1]
+func Mrqmin(x, y, sig, a []float64, ia []bool, funcs Fmodel) (float64, [][]float64, error) {
+	ma := len(a)
+	mfit := 0
+	for _, iia := range ia {
+		if iia {
+			mfit++
+		}
+	}
+	if mfit == 0 {
+		return 0., nil, errors.New("mrqmin: no parameters to be fitted")
+	}
+	alamda := 0.001
+	alpha, beta, chisq := mrqcof(x, y, sig, a, ia, mfit, funcs)
+	atry := make([]float64, ma)
+	converged := 0
+	for iter := 0; iter < 1000 && converged < 4; iter++ {
+		// Augment the diagonal elements of alpha
+		covar := make([][]float64, mfit)
+		oneda := make([][]float64, mfit)
+		for j := 0; j < mfit; j++ {
+			covar[j] = make([]float64, mfit)
+			copy(covar[j], alpha[j])
+			covar[j][j] = alpha[j][j] * (1. + alamda)
+			oneda[j] = []float64{beta[j]}
+		}
+		if err := gaussj(covar, mfit, oneda); err != nil {
+			return 0., nil, err
+		}
+		copy(atry, a)
+		j := -1
+		for l := 0; l < ma; l++ {
+			if ia[l] {
+				j++
+				atry[l] = a[l] + oneda[j][0]
+			}
+		}
+		// Did the trial succeed?
+		talpha, tbeta, tchisq := mrqcof(x, y, sig, atry, ia, mfit, funcs)
+		if math.IsNaN(tchisq) || tchisq >= chisq {
+			alamda *= 10.
+			if math.Abs(tchisq-chisq) <= 1e-10*chisq || alamda > 1e10 {
+				converged++
+			}
+			continue
+		}
+		if chisq-tchisq < 1e-10*chisq+1e-300 {
+			converged++
+		} else {
+			converged = 0
+		}
+		alamda *= 0.1
+		alpha, beta, chisq = talpha, tbeta, tchisq
+		copy(a, atry)
+	}
+	// The covariance matrix is the inverse of alpha
+	covar := make([][]float64, ma)
+	for j := range covar {
+		covar[j] = make([]float64, ma)
+	}
+	for j := 0; j < mfit; j++ {
+		copy(covar[j], alpha[j])
+	}
+	dum := make([][]float64, mfit)
+	for j := range dum {
+		dum[j] = make([]float64, 1)
+	}
+	if err := gaussj(covar, mfit, dum); err != nil {
+		return 0., nil, err
+	}
+	covsrt(covar, ma, ia, mfit)
+	return chisq, covar, nil
+}
+
+// Evaluate the linearized fitting matrix alpha, the vector beta and chi2 used by Mrqmin
+func mrqcof(x, y, sig, a []float64, ia []bool, mfit int, funcs Fmodel) ([][]float64, []float64, float64) {
+	alpha := make([][]float64, mfit)
+	for j := range alpha {
+		alpha[j] = make([]float64, mfit)
+	}
+	beta := make([]float64, mfit)
+	chisq := 0.
+	for i := range x {
+		ymod, dyda := funcs(x[i], a)
+		sig2i := 1. / (sig[i] * sig[i])
+		dy := y[i] - ymod
+		j := -1
+		for l := range a {
+			if ia[l] {
+				wt := dyda[l] * sig2i
+				j++
+				k := -1
+				for m := 0; m <= l; m++ {
+					if ia[m] {
+						k++
+						alpha[j][k] += wt * dyda[m]
+					}
+				}
+				beta[j] += dy * wt
+			}
+		}
+		chisq += dy * dy * sig2i
+	}
+	// Fill in the symmetric side
+	for j := 1; j < mfit; j++ {
+		for k := 0; k < j; k++ {
+			alpha[k][j] = alpha[j][k]
+		}
+	}
+	return alpha, beta, chisq
+}
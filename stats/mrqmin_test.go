@@ -0,0 +1,49 @@
+package stats
+
+import (
+	"math"
+	"testing"
+)
+
+// Used to test automatically Mrqmin
+func mrqmin_innertest(x []float64, model Fmodel, coefs2, guess []float64, t *testing.T) {
+	y := make([]float64, len(x))
+	devs := make([]float64, len(x))
+	for i := range x {
+		y[i], _ = model(x[i], coefs2)
+		devs[i] = 1.0
+	}
+	ia := make([]bool, len(guess))
+	for i := range ia {
+		ia[i] = true
+	}
+	chi2, covar, err := Mrqmin(x, y, devs, guess, ia, model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Verification
+	eps := 1e-3
+	for i := range coefs2 {
+		if math.Abs(guess[i]-coefs2[i]) > eps*math.Abs(coefs2[i]) {
+			t.Errorf("Bad coef: wanted: %f found: %f", coefs2[i], guess[i])
+		}
+		if covar[i][i] < 0 {
+			t.Errorf("Bad variance: %f", covar[i][i])
+		}
+	}
+	if chi2 > eps {
+		t.Errorf("Too big chi2 : wanted: %f found: %f", eps, chi2)
+	}
+}
+
+// Test the fit of a saturation curve
+func TestMrqminSaturation(t *testing.T) {
+	x := []float64{20, 40, 60, 80, 100, 200, 400, 600, 800, 1000, 2000, 4000}
+	mrqmin_innertest(x, FSaturation, []float64{80., 300.}, []float64{50., 100.}, t)
+}
+
+// Test the fit of an inverse curve
+func TestMrqminInverse(t *testing.T) {
+	x := []float64{1, 2, 3, 4, 5, 10, 20, 50}
+	mrqmin_innertest(x, FInverse, []float64{3., 25.}, []float64{1., 1.}, t)
+}
@@ -0,0 +1,99 @@
+package stats
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// CorrectOmission returns the latencies corrected for the coordinated omission of a load generator
+// sending a message every "interval" : a latency v greater than the interval delayed the messages that
+// should have been sent meanwhile, their latencies v - interval, v - 2 interval ... (while >= interval) are added
+// (semantics of recordValueWithExpectedInterval of HdrHistogram)
+// if the corrected latencies are more than max, the long stalls are represented by weighted evenly spaced latencies
+// and the max quantiles of the corrected distribution are returned (sorted) instead of all the latencies
+func CorrectOmission(data []float64, interval float64, max int) ([]float64, error) {
+	if interval <= 0 || max < 1 {
+		return nil, errors.New("CorrectOmission: the interval and the maximum number of latencies must be positive")
+	}
+	// number of added latencies for each latency
+	added := func(v float64) int {
+		if v < 2*interval {
+			return 0
+		}
+		return int(math.Floor(v/interval)) - 1
+	}
+	total := 0
+	for _, v := range data {
+		total += added(v)
+	}
+	if total+len(data) <= max {
+		res := make([]float64, 0, total+len(data))
+		for _, v := range data {
+			res = append(res, v)
+			for m := v - interval; m >= interval; m -= interval {
+				res = append(res, m)
+			}
+		}
+		return res, nil
+	}
+	// weighted latencies : the k latencies added by a stall are represented by at most about max * k / total values
+	var values, weights []float64
+	for _, v := range data {
+		values = append(values, v)
+		weights = append(weights, 1)
+		k := added(v)
+		if k == 0 {
+			continue
+		}
+		s := int(math.Ceil(float64(max) * float64(k) / float64(total)))
+		if s > k {
+			s = k
+		}
+		// the added latencies are spread between v - k interval and v - interval
+		lo, hi := v-float64(k)*interval, v-interval
+		for j := 0; j < s; j++ {
+			values = append(values, lo+(hi-lo)*(float64(j)+0.5)/float64(s))
+			weights = append(weights, float64(k)/float64(s))
+		}
+	}
+	return weightedQuantiles(values, weights, max), nil
+}
+
+// Return the m quantiles of the weighted values at the plotting positions (i + 0.5) / m
+func weightedQuantiles(values, weights []float64, m int) []float64 {
+	idx := make([]int, len(values))
+	sum := 0.
+	for i := range idx {
+		idx[i] = i
+		sum += weights[i]
+	}
+	sort.Slice(idx, func(i, j int) bool {
+		return values[idx[i]] < values[idx[j]]
+	})
+	res := make([]float64, m)
+	k, cum := 0, weights[idx[0]]
+	for i, p := range plottingPositions(m) {
+		for cum < p*sum && k < len(idx)-1 {
+			k++
+			cum += weights[idx[k]]
+		}
+		res[i] = values[idx[k]]
+	}
+	return res
+}
+
+// PercentileSpectrum returns the percentile distribution of the data as drawn by HdrHistogram :
+// the sorted data q and their abscissa x = 1 / (1 - p), p being the percentile of q in ]0, 1[
+func PercentileSpectrum(data []float64) ([]float64, []float64) {
+	n := len(data)
+	q := make([]float64, n)
+	copy(q, data)
+	sort.Float64s(q)
+	x := make([]float64, n)
+	for i := range x {
+		// plotting positions avoiding p = 1
+		x[i] = 1. / (1. - float64(i+1)/float64(n+1))
+	}
+	return x, q
+}
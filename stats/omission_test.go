@@ -0,0 +1,68 @@
+package stats
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestCorrectOmission(t *testing.T) {
+	res, err := CorrectOmission([]float64{1, 5, 10.5}, 2, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wanted := []float64{1, 5, 3, 10.5, 8.5, 6.5, 4.5, 2.5}
+	if !reflect.DeepEqual(res, wanted) {
+		t.Errorf("Bad correction: wanted: %v found: %v", wanted, res)
+	}
+	if _, err = CorrectOmission(res, 0, 100); err == nil {
+		t.Error("Null interval accepted")
+	}
+}
+
+// A stall of 1s with a message every 1ms adds about 1000 samples uniformly spread between 1ms and 1s
+func TestCorrectOmissionStall(t *testing.T) {
+	data := make([]float64, 10000)
+	for i := range data {
+		data[i] = 0.5
+	}
+	data[5000] = 1000
+	res, _ := CorrectOmission(data, 1, 100000)
+	if len(res) != 10999 {
+		t.Fatalf("Bad number of samples: wanted: 10999 found: %d", len(res))
+	}
+	// the raw p99 is 0.5, the corrected one is in the stall
+	if q := Quantile(res, 0.99); math.Abs(q-890) > 2 {
+		t.Errorf("Bad corrected p99: wanted: 890 found: %f", q)
+	}
+}
+
+// Beyond the maximum number of latencies, the quantiles of the corrected distribution are returned
+func TestCorrectOmissionMax(t *testing.T) {
+	data := make([]float64, 10000)
+	for i := range data {
+		data[i] = 0.5
+	}
+	// 2 stalls adding 10^7 latencies each
+	data[3000], data[6000] = 1e7, 1e7
+	res, err := CorrectOmission(data, 1, 50000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 50000 {
+		t.Fatalf("Bad number of latencies: wanted: 50000 found: %d", len(res))
+	}
+	// the added latencies are uniform in [1, 1e7], the raw ones are 1/2000 of the total
+	for _, c := range []struct{ p, q float64 }{{0.5, 5e6}, {0.9, 9e6}, {0.0002, 0.5}} {
+		if q := Quantile(res, c.p); math.Abs(q-c.q) > 0.01*c.q {
+			t.Errorf("Bad quantile %g: wanted: %g found: %g", c.p, c.q, q)
+		}
+	}
+}
+
+func TestPercentileSpectrum(t *testing.T) {
+	x, q := PercentileSpectrum([]float64{3, 1, 2})
+	if !reflect.DeepEqual(q, []float64{1, 2, 3}) || !reflect.DeepEqual(x, []float64{4. / 3., 2, 4}) {
+		t.Errorf("Bad spectrum: found: %v %v", x, q)
+	}
+}
@@ -0,0 +1,122 @@
+package stats
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// Method used to detect the outliers
+type OutlierMethod int
+
+const (
+	OutIQR    OutlierMethod = iota // Tukey's fences : outside [q1 - k iqr, q3 + k iqr]
+	OutMAD                         // further than k scaled median absolute deviations from the median
+	OutHampel                      // Hampel filter : MAD rule applied on a sliding window centered on each point
+)
+
+func (m OutlierMethod) String() string {
+	return [...]string{"iqr", "mad", "hampel"}[m]
+}
+
+// Scale factor making the MAD a consistent estimator of the standard deviation of normal data
+const madScale = 1.4826
+
+// Median returns the median of the data
+func Median(data []float64) float64 {
+	return Quantile(data, 0.5)
+}
+
+// MAD returns the median of the data and its median absolute deviation scaled to the standard deviation
+func MAD(data []float64) (float64, float64) {
+	med := Median(data)
+	dev := make([]float64, len(data))
+	for i, v := range data {
+		dev[i] = math.Abs(v - med)
+	}
+	return med, madScale * Median(dev)
+}
+
+// OutliersIQR returns the indexes of the data outside the Tukey's fences [q1 - k iqr, q3 + k iqr] (usually k = 1.5)
+func OutliersIQR(data []float64, k float64) []int {
+	sorted := make([]float64, len(data))
+	copy(sorted, data)
+	sort.Float64s(sorted)
+	q1, q3 := quantileSorted(sorted, 0.25), quantileSorted(sorted, 0.75)
+	low, high := q1-k*(q3-q1), q3+k*(q3-q1)
+	var idx []int
+	for i, v := range data {
+		if v < low || v > high {
+			idx = append(idx, i)
+		}
+	}
+	return idx
+}
+
+// OutliersMAD returns the indexes of the data further than k scaled MADs from the median (usually k = 3)
+// a null MAD (more than half of the data are equal) gives no outliers
+func OutliersMAD(data []float64, k float64) []int {
+	med, mad := MAD(data)
+	var idx []int
+	if mad == 0 {
+		return idx
+	}
+	for i, v := range data {
+		if math.Abs(v-med) > k*mad {
+			idx = append(idx, i)
+		}
+	}
+	return idx
+}
+
+// Hampel returns the indexes of the data further than k scaled MADs from the median of the window
+// of half width "half" centered on them (the windows are truncated at both ends of the data)
+func Hampel(data []float64, half int, k float64) ([]int, error) {
+	if half < 1 {
+		return nil, errors.New("Hampel: the half width of the window must be positive")
+	}
+	var idx []int
+	n := len(data)
+	for i, v := range data {
+		lo, hi := i-half, i+half+1
+		if lo < 0 {
+			lo = 0
+		}
+		if hi > n {
+			hi = n
+		}
+		med, mad := MAD(data[lo:hi])
+		if mad > 0 && math.Abs(v-med) > k*mad {
+			idx = append(idx, i)
+		}
+	}
+	return idx, nil
+}
+
+// Outliers returns the indexes of the outliers of the data found with the given method
+// k is the threshold of the method, half the half width of the window of the Hampel filter
+func Outliers(data []float64, m OutlierMethod, k float64, half int) ([]int, error) {
+	switch m {
+	case OutIQR:
+		return OutliersIQR(data, k), nil
+	case OutMAD:
+		return OutliersMAD(data, k), nil
+	case OutHampel:
+		return Hampel(data, half, k)
+	}
+	return nil, errors.New("Outliers: unknown method")
+}
+
+// RemoveIndexes returns a copy of the data without the values at the sorted indexes idx
+func RemoveIndexes(data []float64, idx []int) []float64 {
+	res := make([]float64, 0, len(data)-len(idx))
+	j := 0
+	for i, v := range data {
+		if j < len(idx) && idx[j] == i {
+			j++
+			continue
+		}
+		res = append(res, v)
+	}
+	return res
+}
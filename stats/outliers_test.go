@@ -0,0 +1,62 @@
+package stats
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// Normal data with a few large spikes at known positions
+func spiky(n int, seed int64) ([]float64, []int) {
+	r := rand.New(rand.NewSource(seed))
+	data := make([]float64, n)
+	for i := range data {
+		data[i] = 10 + r.NormFloat64()
+	}
+	spikes := []int{10, 250, 600}
+	for _, i := range spikes {
+		data[i] = 1000
+	}
+	return data, spikes
+}
+
+func TestOutliers(t *testing.T) {
+	data, spikes := spiky(1000, 1)
+	for _, m := range []OutlierMethod{OutIQR, OutMAD, OutHampel} {
+		// large thresholds so that only the spikes are detected
+		idx, err := Outliers(data, m, 6, 20)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(idx, spikes) {
+			t.Errorf("Bad outliers with %s: wanted: %v found: %v", m, spikes, idx)
+		}
+	}
+}
+
+// The Hampel filter detects local spikes hidden in a trend
+func TestHampelTrend(t *testing.T) {
+	data := make([]float64, 200)
+	for i := range data {
+		data[i] = float64(i) + 0.1*float64(i%3)
+	}
+	data[150] = 100
+	idx, err := Hampel(data, 5, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(idx, []int{150}) {
+		t.Errorf("Bad outliers: wanted: [150] found: %v", idx)
+	}
+	// the global rules do not see it
+	if idx = OutliersMAD(data, 3); len(idx) != 0 {
+		t.Errorf("Bad outliers: wanted: [] found: %v", idx)
+	}
+}
+
+func TestRemoveIndexes(t *testing.T) {
+	res := RemoveIndexes([]float64{0, 1, 2, 3, 4}, []int{0, 2, 4})
+	if !reflect.DeepEqual(res, []float64{1, 3}) {
+		t.Errorf("Bad removal: wanted: [1 3] found: %v", res)
+	}
+}
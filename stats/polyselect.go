@@ -0,0 +1,158 @@
+package stats
+
+import (
+	"errors"
+	"math"
+)
+
+// Criterion used to select the degree of a polynomial fit
+type Criterion int
+
+const (
+	CritAIC   Criterion = iota // Akaike information criterion (lowest)
+	CritAdjR2                  // adjusted coefficient of determination (highest)
+	CritCV                     // leave-one-out cross-validation error (lowest)
+)
+
+func (c Criterion) String() string {
+	return [...]string{"aic", "adjr2", "cv"}[c]
+}
+
+// PolyFit holds a polynomial fit and its diagnostics
+type PolyFit struct {
+	Poly
+	Degree    int
+	Residuals []float64   // y - fit at each data point
+	R2, AdjR2 float64     // coefficient of determination, and adjusted to the number of parameters
+	AIC       float64     // Akaike information criterion n ln(chi2 / n) + 2 (degree + 1)
+	CV        float64     // leave-one-out cross-validation weighted mean squared error
+	Covar     [][]float64 // covariance matrix of the coefficients, scaled by the reduced chi2
+	sig2      float64     // mean variance of the data points, used by the prediction band
+	tq        float64     // Student's t quantile of the bands
+}
+
+// Bands returns the half-widths of the 95% confidence band of the fit and of the prediction band at x
+func (f PolyFit) Bands(x float64) (float64, float64) {
+	g := Fcoefs((x-f.Center)/f.Scale, f.Coefs)
+	v := 0.
+	for i := range g {
+		for j := range g {
+			v += g[i] * f.Covar[i][j] * g[j]
+		}
+	}
+	return f.tq * math.Sqrt(v), f.tq * math.Sqrt(v+f.sig2)
+}
+
+// FitPolyDiag fits the data x, y (with deviations sig) with a polynom of the given degree and computes its diagnostics
+// all the points have the same weight if sig is nil
+func FitPolyDiag(x, y, sig []float64, degree int) (PolyFit, error) {
+	n := len(x)
+	m := degree + 1
+	if n <= m {
+		return PolyFit{}, errors.New("FitPolyDiag: not enough data points for the degree")
+	}
+	if sig == nil {
+		sig = make([]float64, n)
+		for i := range sig {
+			sig[i] = 1.
+		}
+	}
+	for i := range sig {
+		if sig[i] <= 0 {
+			return PolyFit{}, errors.New("FitPolyDiag: the deviations must be positive")
+		}
+	}
+	poly, chisq, covar, err := Polyfit(x, y, sig, m)
+	if err != nil {
+		return PolyFit{}, err
+	}
+	f := PolyFit{Poly: poly, Degree: degree, Residuals: make([]float64, n)}
+	// weighted mean of y
+	sw, swy := 0., 0.
+	for i := range x {
+		w := 1. / (sig[i] * sig[i])
+		sw += w
+		swy += w * y[i]
+		f.Residuals[i] = y[i] - poly.Eval(x[i])
+		f.sig2 += sig[i] * sig[i]
+	}
+	f.sig2 /= float64(n)
+	ymean := swy / sw
+	tss := 0.
+	for i := range x {
+		tss += (y[i] - ymean) * (y[i] - ymean) / (sig[i] * sig[i])
+	}
+	an := float64(n)
+	f.R2 = 1.
+	if tss > 0 {
+		f.R2 = 1. - chisq/tss
+	}
+	f.AdjR2 = 1. - (1.-f.R2)*(an-1.)/(an-float64(m))
+	f.AIC = an*math.Log(math.Max(chisq, 1e-300)/an) + 2.*float64(m)
+	// scale the covariance matrix and the data variance by the reduced chi2
+	red := chisq / (an - float64(m))
+	f.Covar = covar
+	for i := range covar {
+		for j := range covar[i] {
+			f.Covar[i][j] *= red
+		}
+	}
+	f.sig2 *= red
+	f.tq = StudentTQuantile(0.975, an-float64(m))
+	// leave-one-out cross-validation
+	if n > m+1 {
+		xx := make([]float64, n-1)
+		yy := make([]float64, n-1)
+		ss := make([]float64, n-1)
+		for k := range x {
+			copy(xx, x[:k])
+			copy(xx[k:], x[k+1:])
+			copy(yy, y[:k])
+			copy(yy[k:], y[k+1:])
+			copy(ss, sig[:k])
+			copy(ss[k:], sig[k+1:])
+			p, _, _, err := Polyfit(xx, yy, ss, m)
+			if err != nil {
+				return PolyFit{}, err
+			}
+			e := (y[k] - p.Eval(x[k])) / sig[k]
+			f.CV += e * e
+		}
+		f.CV /= an
+	} else {
+		f.CV = math.Inf(1)
+	}
+	return f, nil
+}
+
+// SelectPoly fits the data with the polynoms of degrees 1..kmax and returns the best one according to the criterion
+// the degrees that leave less than 2 degrees of freedom are skipped, the fits are unweighted if sig is nil
+func SelectPoly(x, y, sig []float64, kmax int, crit Criterion) (PolyFit, error) {
+	var best PolyFit
+	found := false
+	for k := 1; k <= kmax && k+2 < len(x); k++ {
+		f, err := FitPolyDiag(x, y, sig, k)
+		if err != nil {
+			return PolyFit{}, err
+		}
+		if !found || better(f, best, crit) {
+			best = f
+			found = true
+		}
+	}
+	if !found {
+		return PolyFit{}, errors.New("SelectPoly: not enough data points")
+	}
+	return best, nil
+}
+
+// Return true if the fit f is better than g according to the criterion
+func better(f, g PolyFit, crit Criterion) bool {
+	switch crit {
+	case CritAdjR2:
+		return f.AdjR2 > g.AdjR2
+	case CritCV:
+		return f.CV < g.CV
+	}
+	return f.AIC < g.AIC
+}
@@ -0,0 +1,59 @@
+package stats
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// The degree of a noisy trinome should be found by all the criteria
+func TestSelectPoly(t *testing.T) {
+	r := rand.New(rand.NewSource(5))
+	x := make([]float64, 30)
+	y := make([]float64, 30)
+	devs := make([]float64, 30)
+	for i := range x {
+		x[i] = float64(i)
+		y[i] = Fpoly(x[i], []float64{1., -2., 0.5}) + 0.5*r.NormFloat64()
+		devs[i] = 0.5
+	}
+	for _, crit := range []Criterion{CritAIC, CritAdjR2, CritCV} {
+		f, err := SelectPoly(x, y, devs, 6, crit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.Degree != 2 {
+			t.Errorf("Bad degree with %s: wanted: 2 found: %d", crit, f.Degree)
+		}
+		conf, pred := f.Bands(15)
+		if conf <= 0 || pred <= conf {
+			t.Errorf("Bad bands with %s: conf=%f pred=%f", crit, conf, pred)
+		}
+	}
+}
+
+// A zero deviation is refused, the unweighted fit is the fit with unit deviations
+func TestFitPolyDiagSig(t *testing.T) {
+	x := []float64{0, 1, 2, 3, 4, 5}
+	y := []float64{1, 3, 4, 8, 9, 11}
+	devs := []float64{1, 1, 0, 1, 1, 1}
+	if _, err := FitPolyDiag(x, y, devs, 1); err == nil {
+		t.Error("No error with a zero deviation")
+	}
+	if _, err := SelectPoly(x, y, devs, 2, CritAIC); err == nil {
+		t.Error("No error of the selection with a zero deviation")
+	}
+	devs[2] = 1
+	f, err := FitPolyDiag(x, y, nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := FitPolyDiag(x, y, devs, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(f.Coefs, g.Coefs) || f.R2 != g.R2 || f.AIC != g.AIC || math.IsNaN(f.CV) {
+		t.Errorf("Bad unweighted fit: %v r2=%f aic=%f cv=%f, wanted: %v r2=%f aic=%f", f.Coefs, f.R2, f.AIC, f.CV, g.Coefs, g.R2, g.AIC)
+	}
+}
@@ -0,0 +1,116 @@
+package stats
+
+import (
+	"math"
+	"sort"
+)
+
+// Return the quantile p (in [0, 1]) of the sorted data, linear interpolation between the closest ranks
+func quantileSorted(sorted []float64, p float64) float64 {
+	n := len(sorted)
+	if n == 0 {
+		return math.NaN()
+	}
+	h := p * float64(n-1)
+	i := int(h)
+	if i >= n-1 {
+		return sorted[n-1]
+	}
+	return sorted[i] + (h-float64(i))*(sorted[i+1]-sorted[i])
+}
+
+// Quantile returns the quantile p (in [0, 1]) of the data
+func Quantile(data []float64, p float64) float64 {
+	sorted := make([]float64, len(data))
+	copy(sorted, data)
+	sort.Float64s(sorted)
+	return quantileSorted(sorted, p)
+}
+
+// ECDF returns the empirical cumulative distribution function of the data
+// x : the sorted data, p[i] : the fraction of the data lower or equal to x[i]
+func ECDF(data []float64) ([]float64, []float64) {
+	n := len(data)
+	x := make([]float64, n)
+	copy(x, data)
+	sort.Float64s(x)
+	p := make([]float64, n)
+	for i := range x {
+		p[i] = float64(i+1) / float64(n)
+	}
+	// equal values share the same probability
+	for i := n - 2; i >= 0; i-- {
+		if x[i] == x[i+1] {
+			p[i] = p[i+1]
+		}
+	}
+	return x, p
+}
+
+// CCDF returns the empirical complementary cumulative distribution function (aka survival function) of the data
+// x : the sorted data, p[i] : the fraction of the data strictly greater than x[i]
+func CCDF(data []float64) ([]float64, []float64) {
+	x, p := ECDF(data)
+	for i := range p {
+		p[i] = 1. - p[i]
+	}
+	return x, p
+}
+
+// Exceedance returns the fraction of the data strictly greater than the threshold
+func Exceedance(data []float64, threshold float64) float64 {
+	if len(data) == 0 {
+		return 0
+	}
+	n := 0
+	for _, d := range data {
+		if d > threshold {
+			n++
+		}
+	}
+	return float64(n) / float64(len(data))
+}
+
+// Return m plotting positions (i + 0.5) / m, i in [0, m-1]
+func plottingPositions(m int) []float64 {
+	p := make([]float64, m)
+	for i := range p {
+		p[i] = (float64(i) + 0.5) / float64(m)
+	}
+	return p
+}
+
+// QQ returns the points of the quantile-quantile plot of the data against the distribution d
+// using at most m quantiles: theo are the theoretical quantiles and sample the data quantiles
+func QQ(data []float64, d Distribution, m int) ([]float64, []float64) {
+	if m > len(data) {
+		m = len(data)
+	}
+	sorted := make([]float64, len(data))
+	copy(sorted, data)
+	sort.Float64s(sorted)
+	theo := make([]float64, m)
+	sample := make([]float64, m)
+	for i, p := range plottingPositions(m) {
+		theo[i] = d.Quantile(p)
+		sample[i] = quantileSorted(sorted, p)
+	}
+	return theo, sample
+}
+
+// QQ2 returns the points of the two-sample quantile-quantile plot of a against b using m quantiles
+func QQ2(a, b []float64, m int) ([]float64, []float64) {
+	sa := make([]float64, len(a))
+	copy(sa, a)
+	sort.Float64s(sa)
+	sb := make([]float64, len(b))
+	copy(sb, b)
+	sort.Float64s(sb)
+	qa := make([]float64, m)
+	qb := make([]float64, m)
+	for i, p := range plottingPositions(m) {
+		qa[i] = quantileSorted(sa, p)
+		qb[i] = quantileSorted(sb, p)
+	}
+	return qa, qb
+}
@@ -0,0 +1,94 @@
+package stats
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+// Quantiles at the bounds, between the ranks and of empty data
+func TestQuantile(t *testing.T) {
+	data := []float64{4, 1, 3, 2}
+	for _, c := range []struct{ p, q float64 }{{0, 1}, {1, 4}, {0.5, 2.5}, {1. / 3., 2}, {0.9, 3.7}} {
+		if q := Quantile(data, c.p); math.Abs(q-c.q) > 1e-12 {
+			t.Errorf("Bad quantile %g: wanted: %g found: %g", c.p, c.q, q)
+		}
+	}
+	if !reflect.DeepEqual(data, []float64{4, 1, 3, 2}) {
+		t.Errorf("Data modified: %v", data)
+	}
+	if q := Quantile([]float64{7}, 0.3); q != 7 {
+		t.Errorf("Bad quantile of a single value: wanted: 7 found: %g", q)
+	}
+	if q := Quantile(nil, 0.5); !math.IsNaN(q) {
+		t.Errorf("Bad quantile of empty data: wanted: NaN found: %g", q)
+	}
+}
+
+// Equal values share the probability of the last one
+func TestECDF(t *testing.T) {
+	x, p := ECDF([]float64{3, 1, 2, 2, 3, 3})
+	if !reflect.DeepEqual(x, []float64{1, 2, 2, 3, 3, 3}) {
+		t.Errorf("Bad ECDF values: %v", x)
+	}
+	wanted := []float64{1. / 6., 3. / 6., 3. / 6., 1, 1, 1}
+	for i := range p {
+		if math.Abs(p[i]-wanted[i]) > 1e-12 {
+			t.Errorf("Bad ECDF: wanted: %v found: %v", wanted, p)
+			break
+		}
+	}
+	x, p = ECDF(nil)
+	if len(x) != 0 || len(p) != 0 {
+		t.Errorf("ECDF of empty data: %v %v", x, p)
+	}
+}
+
+// The complementary distribution is 0 at the maximum, ties included
+func TestCCDF(t *testing.T) {
+	x, p := CCDF([]float64{2, 1, 2})
+	if !reflect.DeepEqual(x, []float64{1, 2, 2}) {
+		t.Errorf("Bad CCDF values: %v", x)
+	}
+	wanted := []float64{2. / 3., 0, 0}
+	for i := range p {
+		if math.Abs(p[i]-wanted[i]) > 1e-12 {
+			t.Errorf("Bad CCDF: wanted: %v found: %v", wanted, p)
+			break
+		}
+	}
+}
+
+// The values equal to the threshold do not exceed it
+func TestExceedance(t *testing.T) {
+	data := []float64{1, 2, 2, 3}
+	for _, c := range []struct{ s, p float64 }{{0, 1}, {2, 0.25}, {1.5, 0.75}, {3, 0}} {
+		if p := Exceedance(data, c.s); p != c.p {
+			t.Errorf("Bad exceedance of %g: wanted: %g found: %g", c.s, c.p, p)
+		}
+	}
+	if p := Exceedance(nil, 1); p != 0 {
+		t.Errorf("Bad exceedance of empty data: wanted: 0 found: %g", p)
+	}
+}
+
+// Two-sample Q-Q plot of samples of different sizes, of a sample against itself and of an empty sample
+func TestQQ2(t *testing.T) {
+	a := []float64{5, 1, 3}
+	qa, qb := QQ2(a, []float64{10, 30, 20, 40, 50}, 2)
+	// plotting positions 0.25 and 0.75
+	if !reflect.DeepEqual(qa, []float64{2, 4}) || !reflect.DeepEqual(qb, []float64{20, 40}) {
+		t.Errorf("Bad Q-Q points: %v %v", qa, qb)
+	}
+	qa, qb = QQ2(a, a, 10)
+	if !reflect.DeepEqual(qa, qb) {
+		t.Errorf("Q-Q points of the same sample not on the bisector: %v %v", qa, qb)
+	}
+	qa, qb = QQ2(a, nil, 3)
+	for i := range qb {
+		if !math.IsNaN(qb[i]) || math.IsNaN(qa[i]) {
+			t.Errorf("Bad Q-Q points with an empty sample: %v %v", qa, qb)
+			break
+		}
+	}
+}
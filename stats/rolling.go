@@ -0,0 +1,184 @@
+package stats
+
+import (
+	"errors"
+	"math"
+)
+
+// Node of an order statistic treap
+type tnode struct {
+	value       float64
+	prio        uint32
+	size        int // number of nodes of the subtree
+	left, right *tnode
+}
+
+func (t *tnode) len() int {
+	if t == nil {
+		return 0
+	}
+	return t.size
+}
+
+func (t *tnode) update() {
+	t.size = 1 + t.left.len() + t.right.len()
+}
+
+// Split the treap into the values < v (or <= v if orEqual) and the others
+func split(t *tnode, v float64, orEqual bool) (*tnode, *tnode) {
+	if t == nil {
+		return nil, nil
+	}
+	if t.value < v || (orEqual && t.value == v) {
+		l, r := split(t.right, v, orEqual)
+		t.right = l
+		t.update()
+		return t, r
+	}
+	l, r := split(t.left, v, orEqual)
+	t.left = r
+	t.update()
+	return l, t
+}
+
+// Merge two treaps, all the values of l being lower or equal to those of r
+func merge(l, r *tnode) *tnode {
+	if l == nil {
+		return r
+	}
+	if r == nil {
+		return l
+	}
+	if l.prio > r.prio {
+		l.right = merge(l.right, r)
+		l.update()
+		return l
+	}
+	r.left = merge(l, r.left)
+	r.update()
+	return r
+}
+
+// OrderStat is a multiset of values giving the k-th smallest value in O(log n) (randomized balanced tree)
+type OrderStat struct {
+	root *tnode
+	seed uint32
+}
+
+// Len returns the number of values in the set
+func (o *OrderStat) Len() int {
+	return o.root.len()
+}
+
+// Insert adds the value v to the set
+func (o *OrderStat) Insert(v float64) {
+	// xorshift generator of the priorities
+	if o.seed == 0 {
+		o.seed = 2463534242
+	}
+	o.seed ^= o.seed << 13
+	o.seed ^= o.seed >> 17
+	o.seed ^= o.seed << 5
+	l, r := split(o.root, v, false)
+	o.root = merge(merge(l, &tnode{value: v, prio: o.seed, size: 1}), r)
+}
+
+// Delete removes one occurrence of the value v, returns false if v is not in the set
+func (o *OrderStat) Delete(v float64) bool {
+	l, r := split(o.root, v, false)
+	m, r := split(r, v, true)
+	found := m != nil
+	if found {
+		m = merge(m.left, m.right)
+	}
+	o.root = merge(merge(l, m), r)
+	return found
+}
+
+// Kth returns the k-th smallest value (k in [0, Len()[)
+func (o *OrderStat) Kth(k int) float64 {
+	t := o.root
+	for {
+		l := t.left.len()
+		switch {
+		case k < l:
+			t = t.left
+		case k == l:
+			return t.value
+		default:
+			k -= l + 1
+			t = t.right
+		}
+	}
+}
+
+// Quantile returns the quantile p (in [0, 1]) of the set, linear interpolation between the closest ranks
+func (o *OrderStat) Quantile(p float64) float64 {
+	n := o.Len()
+	if n == 0 {
+		return math.NaN()
+	}
+	h := p * float64(n-1)
+	i := int(h)
+	if i >= n-1 {
+		return o.Kth(n - 1)
+	}
+	lo := o.Kth(i)
+	return lo + (h-float64(i))*(o.Kth(i+1)-lo)
+}
+
+// RollingQuantiles returns the quantiles ps of the sliding windows data[i..i+w-1]
+// q[k][i] is the quantile ps[k] of the i-th window, i in [0, len(data)-w]
+func RollingQuantiles(data []float64, w int, ps []float64) ([][]float64, error) {
+	if w < 1 || w > len(data) {
+		return nil, errors.New("RollingQuantiles: the window must be in [1, len(data)]")
+	}
+	q := make([][]float64, len(ps))
+	for k := range q {
+		q[k] = make([]float64, len(data)-w+1)
+	}
+	var o OrderStat
+	for i, v := range data {
+		o.Insert(v)
+		if i >= w {
+			o.Delete(data[i-w])
+		}
+		if i >= w-1 {
+			for k, p := range ps {
+				q[k][i-w+1] = o.Quantile(p)
+			}
+		}
+	}
+	return q, nil
+}
+
+// RollingQuantilesTime returns the quantiles ps of the sliding time windows ]t[i] - width, t[i]]
+// the times t must be sorted, q[k][i] is the quantile ps[k] of the window ending at t[i]
+func RollingQuantilesTime(t, data []float64, width float64, ps []float64) ([][]float64, error) {
+	if len(t) != len(data) {
+		return nil, errors.New("RollingQuantilesTime: t and data must have the same length")
+	}
+	if width <= 0 {
+		return nil, errors.New("RollingQuantilesTime: the width must be positive")
+	}
+	q := make([][]float64, len(ps))
+	for k := range q {
+		q[k] = make([]float64, len(data))
+	}
+	var o OrderStat
+	j := 0
+	for i, v := range data {
+		if i > 0 && t[i] < t[i-1] {
+			return nil, errors.New("RollingQuantilesTime: the times must be sorted")
+		}
+		o.Insert(v)
+		for t[j] <= t[i]-width {
+			o.Delete(data[j])
+			j++
+		}
+		for k, p := range ps {
+			q[k][i] = o.Quantile(p)
+		}
+	}
+	return q, nil
+}
@@ -0,0 +1,77 @@
+package stats
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestOrderStat(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	var o OrderStat
+	var values []float64
+	for i := 0; i < 1000; i++ {
+		// many duplicates
+		v := float64(r.Intn(50))
+		o.Insert(v)
+		values = append(values, v)
+	}
+	for _, v := range values[:500] {
+		if !o.Delete(v) {
+			t.Fatalf("Value %f not found", v)
+		}
+	}
+	if o.Delete(100) {
+		t.Error("Deleted a missing value")
+	}
+	if o.Len() != 500 {
+		t.Errorf("Bad length: wanted: 500 found: %d", o.Len())
+	}
+	for _, p := range []float64{0, 0.5, 0.95, 1} {
+		if o.Quantile(p) != Quantile(values[500:], p) {
+			t.Errorf("Bad quantile %f: wanted: %f found: %f", p, Quantile(values[500:], p), o.Quantile(p))
+		}
+	}
+}
+
+// The rolling quantiles are the quantiles of each window
+func TestRollingQuantiles(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	data := make([]float64, 300)
+	for i := range data {
+		data[i] = r.ExpFloat64()
+	}
+	ps := []float64{0.5, 0.99}
+	w := 40
+	q, err := RollingQuantiles(data, w, ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(q[0]) != len(data)-w+1 {
+		t.Fatalf("Bad number of windows: wanted: %d found: %d", len(data)-w+1, len(q[0]))
+	}
+	for i := range q[0] {
+		for k, p := range ps {
+			if math.Abs(q[k][i]-Quantile(data[i:i+w], p)) > 1e-12 {
+				t.Errorf("Bad quantile %f of window %d: wanted: %f found: %f", p, i, Quantile(data[i:i+w], p), q[k][i])
+			}
+		}
+	}
+}
+
+func TestRollingQuantilesTime(t *testing.T) {
+	ts := []float64{0, 0.5, 1, 1.2, 3, 3.1}
+	data := []float64{1, 2, 3, 4, 5, 6}
+	q, err := RollingQuantilesTime(ts, data, 1, []float64{0, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// windows ]t - 1, t]
+	mins := []float64{1, 1, 2, 2, 5, 5}
+	maxs := []float64{1, 2, 3, 4, 5, 6}
+	for i := range ts {
+		if q[0][i] != mins[i] || q[1][i] != maxs[i] {
+			t.Errorf("Bad window %d: wanted: [%f %f] found: [%f %f]", i, mins[i], maxs[i], q[0][i], q[1][i])
+		}
+	}
+}
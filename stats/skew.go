@@ -0,0 +1,65 @@
+package stats
+
+import (
+	"errors"
+	"sort"
+)
+
+// LowerHull returns the indexes of the points (x[i], y[i]) on the lower convex hull, sorted by increasing x
+func LowerHull(x, y []float64) []int {
+	idx := make([]int, len(x))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.Slice(idx, func(a, b int) bool {
+		if x[idx[a]] == x[idx[b]] {
+			return y[idx[a]] < y[idx[b]]
+		}
+		return x[idx[a]] < x[idx[b]]
+	})
+	// Andrew's monotone chain
+	var hull []int
+	for k, i := range idx {
+		// only the lowest point of each abscissa
+		if k > 0 && x[i] == x[idx[k-1]] {
+			continue
+		}
+		for len(hull) >= 2 {
+			o, a := hull[len(hull)-2], hull[len(hull)-1]
+			// remove a if it is not strictly below the segment o-i
+			if (x[a]-x[o])*(y[i]-y[o])-(y[a]-y[o])*(x[i]-x[o]) > 0 {
+				break
+			}
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, i)
+	}
+	return hull
+}
+
+// MinDelayEnvelope estimates the clock skew between two hosts from the one way delays d[i] = receive - send
+// measured at the send times t[i] : the offset and drift of the line a + b t lying below all the delays
+// and as close as possible to them (minimum sum of the distances), which is the edge of the lower convex hull
+// containing the mean of the times (Moon, Skelly and Towsley 1999)
+// The delays corrected for the skew d - (a + b t) are the delays above the minimum delay
+func MinDelayEnvelope(t, d []float64) (float64, float64, error) {
+	if len(t) != len(d) || len(t) == 0 {
+		return 0, 0, errors.New("MinDelayEnvelope: t and d must have the same positive length")
+	}
+	hull := LowerHull(t, d)
+	if len(hull) == 1 {
+		return d[hull[0]], 0, nil
+	}
+	mean := 0.
+	for _, v := range t {
+		mean += v
+	}
+	mean /= float64(len(t))
+	k := 0
+	for k < len(hull)-2 && t[hull[k+1]] < mean {
+		k++
+	}
+	i, j := hull[k], hull[k+1]
+	b := (d[j] - d[i]) / (t[j] - t[i])
+	return d[i] - b*t[i], b, nil
+}
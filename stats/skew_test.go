@@ -0,0 +1,41 @@
+package stats
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestLowerHull(t *testing.T) {
+	x := []float64{0, 1, 2, 3, 4, 2}
+	y := []float64{1, 0, 2, 0.5, 3, 0.2}
+	if hull := LowerHull(x, y); !reflect.DeepEqual(hull, []int{0, 1, 5, 3, 4}) {
+		t.Errorf("Bad hull: wanted: [0 1 5 3 4] found: %v", hull)
+	}
+}
+
+// Delays = minimum delay + queueing delays (exponential) + a clock offset and drift
+func TestMinDelayEnvelope(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	n := 5000
+	ts := make([]float64, n)
+	d := make([]float64, n)
+	offset, drift := -250., 0.05 // ms and ms/s (50 ppm)
+	for i := range ts {
+		ts[i] = float64(i) * 0.01
+		d[i] = 2 + offset + drift*ts[i] + r.ExpFloat64()
+	}
+	a, b, err := MinDelayEnvelope(ts, d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(b-drift) > 0.005 || math.Abs(a-(2+offset)) > 0.05 {
+		t.Errorf("Bad envelope: wanted: a=%f b=%f found: a=%f b=%f", 2+offset, drift, a, b)
+	}
+	for i := range ts {
+		if d[i]-(a+b*ts[i]) < -1e-9 {
+			t.Fatalf("Envelope above the delay %d", i)
+		}
+	}
+}
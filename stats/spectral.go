@@ -0,0 +1,124 @@
+package stats
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// Resample interpolates linearly the series (t[i], y[i]) onto the uniform grid t0, t0 + dt, ... <= tmax
+// the points do not need to be sorted by time
+// Returns the grid and the interpolated values
+func Resample(t, y []float64, dt float64) ([]float64, []float64, error) {
+	n := len(t)
+	if n < 2 || dt <= 0 {
+		return nil, nil, errors.New("Resample: need at least 2 points and dt > 0")
+	}
+	idx := make([]int, n)
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(i, j int) bool {
+		return t[idx[i]] < t[idx[j]]
+	})
+	t0, tmax := t[idx[0]], t[idx[n-1]]
+	m := int((tmax-t0)/dt) + 1
+	grid := make([]float64, m)
+	values := make([]float64, m)
+	k := 0
+	for i := range grid {
+		grid[i] = t0 + float64(i)*dt
+		for k < n-2 && t[idx[k+1]] < grid[i] {
+			k++
+		}
+		ta, tb := t[idx[k]], t[idx[k+1]]
+		ya, yb := y[idx[k]], y[idx[k+1]]
+		if tb == ta {
+			values[i] = (ya + yb) / 2.
+		} else {
+			values[i] = ya + (yb-ya)*(grid[i]-ta)/(tb-ta)
+		}
+	}
+	return grid, values, nil
+}
+
+// In-place radix-2 fast Fourier transform of the complex series (re, im), len must be a power of 2
+func fft(re, im []float64) {
+	n := len(re)
+	// bit reversal permutation
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			re[i], re[j] = re[j], re[i]
+			im[i], im[j] = im[j], im[i]
+		}
+	}
+	for size := 2; size <= n; size <<= 1 {
+		ang := -2. * math.Pi / float64(size)
+		wr, wi := math.Cos(ang), math.Sin(ang)
+		for start := 0; start < n; start += size {
+			cr, ci := 1., 0.
+			for k := 0; k < size/2; k++ {
+				a, b := start+k, start+k+size/2
+				tr := re[b]*cr - im[b]*ci
+				ti := re[b]*ci + im[b]*cr
+				re[b], im[b] = re[a]-tr, im[a]-ti
+				re[a], im[a] = re[a]+tr, im[a]+ti
+				cr, ci = cr*wr-ci*wi, cr*wi+ci*wr
+			}
+		}
+	}
+}
+
+// Periodogram returns the power spectrum of the uniformly sampled series y (time step dt)
+// the mean is removed and the series is zero padded to the next power of 2
+// Returns the frequencies (in 1 / unit of dt) in ]0, 1 / (2 dt)] and the corresponding powers
+func Periodogram(y []float64, dt float64) ([]float64, []float64, error) {
+	if len(y) < 4 {
+		return nil, nil, errors.New("Periodogram: n must be at least 4")
+	}
+	n := 1
+	for n < len(y) {
+		n <<= 1
+	}
+	re := make([]float64, n)
+	im := make([]float64, n)
+	mean := MeanF64(y)
+	for i := range y {
+		re[i] = y[i] - mean
+	}
+	fft(re, im)
+	freqs := make([]float64, n/2)
+	power := make([]float64, n/2)
+	for k := range freqs {
+		freqs[k] = float64(k+1) / (float64(n) * dt)
+		power[k] = (re[k+1]*re[k+1] + im[k+1]*im[k+1]) * dt / float64(len(y))
+	}
+	return freqs, power, nil
+}
+
+// DominantPeriods returns the periods (1 / f) of the k highest local maxima of the power spectrum
+// sorted by decreasing power
+func DominantPeriods(freqs, power []float64, k int) []float64 {
+	var peaks []int
+	for i := range power {
+		if (i == 0 || power[i] > power[i-1]) && (i == len(power)-1 || power[i] >= power[i+1]) {
+			peaks = append(peaks, i)
+		}
+	}
+	sort.SliceStable(peaks, func(i, j int) bool {
+		return power[peaks[i]] > power[peaks[j]]
+	})
+	if len(peaks) > k {
+		peaks = peaks[:k]
+	}
+	periods := make([]float64, len(peaks))
+	for i, p := range peaks {
+		periods[i] = 1. / freqs[p]
+	}
+	return periods
+}
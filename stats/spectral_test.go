@@ -0,0 +1,48 @@
+package stats
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// The dominant period of a noisy sine irregularly sampled should be found after resampling
+func TestDominantPeriods(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	n := 5000
+	ts := make([]float64, n)
+	y := make([]float64, n)
+	tt := 0.
+	for i := range ts {
+		tt += 0.002 * r.ExpFloat64()
+		ts[i] = tt
+		y[i] = 5. + math.Sin(2.*math.Pi*tt/0.7) + 0.3*r.NormFloat64()
+	}
+	_, values, err := Resample(ts, y, 0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	freqs, power, err := Periodogram(values, 0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	periods := DominantPeriods(freqs, power, 3)
+	if len(periods) == 0 || math.Abs(periods[0]-0.7) > 0.05 {
+		t.Errorf("Bad dominant period: wanted: 0.7 found: %v", periods)
+	}
+}
+
+// The FFT of a constant is a Dirac at frequency 0
+func TestFFT(t *testing.T) {
+	re := []float64{1, 1, 1, 1, 1, 1, 1, 1}
+	im := make([]float64, 8)
+	fft(re, im)
+	if math.Abs(re[0]-8) > 1e-12 {
+		t.Errorf("Bad FFT at 0: wanted: 8 found: %f", re[0])
+	}
+	for k := 1; k < 8; k++ {
+		if math.Abs(re[k]) > 1e-12 || math.Abs(im[k]) > 1e-12 {
+			t.Errorf("Bad FFT at %d: wanted: 0 found: %f %f", k, re[k], im[k])
+		}
+	}
+}
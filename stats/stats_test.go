@@ -2,6 +2,7 @@ package stats
 
 import (
 	"math"
+	"reflect"
 	"testing"
 )
 
@@ -99,3 +100,47 @@ func TestLsFitLinear_Hor(t *testing.T) {
 func TestLsFitLinear_Vert(t *testing.T) {
 	lsFitLinear_innertest(100, []float64{0., 1.}, t)
 }
+
+// Buckets of empty timestamps, of a single bucket, of unsorted timestamps and of timestamps on the bucket edges
+func TestBucketCounts(t *testing.T) {
+	for _, c := range []struct {
+		name           string
+		ts             []int64
+		starts, counts []float64
+		fails          bool
+	}{
+		{"empty", nil, nil, nil, true},
+		{"single bucket", []int64{105, 101, 109}, []float64{0}, []float64{3}, false},
+		{"unsorted", []int64{30, 10, 11, 25, 19, 40}, []float64{0, 10, 20, 30}, []float64{3, 1, 1, 1}, false},
+		{"edges", []int64{100, 110, 120, 119}, []float64{0, 10, 20}, []float64{1, 2, 1}, false},
+	} {
+		starts, counts, err := BucketCounts(c.ts, 10)
+		if (err != nil) != c.fails || !reflect.DeepEqual(starts, c.starts) || !reflect.DeepEqual(counts, c.counts) {
+			t.Errorf("Bad buckets %s: wanted: %v %v found: %v %v %v", c.name, c.starts, c.counts, starts, counts, err)
+		}
+	}
+	if _, _, err := BucketCounts([]int64{1, 2}, 0); err == nil {
+		t.Error("No error for an empty bucket width")
+	}
+}
+
+// Coefficient of variation of a constant series, of a zero mean series and of too few values
+func TestCoefVariation(t *testing.T) {
+	for _, c := range []struct {
+		name     string
+		data     []float64
+		mean, cv float64
+		fails    bool
+	}{
+		{"constant", []float64{2, 2, 2}, 2, 0, false},
+		{"zero mean", []float64{-1, 1}, 0, math.Inf(1), false},
+		{"two values", []float64{1, 3}, 2, math.Sqrt(2) / 2, false},
+		{"single value", []float64{5}, 0, 0, true},
+		{"empty", nil, 0, 0, true},
+	} {
+		mean, cv, err := CoefVariation(c.data)
+		if (err != nil) != c.fails || mean != c.mean || (cv != c.cv && math.Abs(cv-c.cv) > 1e-12) {
+			t.Errorf("Bad coefficient of variation %s: wanted: %g %g found: %g %g %v", c.name, c.mean, c.cv, mean, cv, err)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package stats
+
+import (
+	"errors"
+	"math"
+	"plots/sliceutil"
+)
+
+// Singular values smaller than TOL times the largest one are set to zero by Svdfit
+const TOL = 1.e-12
+
+// Singular value decomposition of a[0..m-1][0..n-1] (m >= n) by one-sided Jacobi rotations
+// a = u diag(w) v^T with u[0..m-1][0..n-1], w[0..n-1] and v[0..n-1][0..n-1]
+func svdcmp(a [][]float64) ([][]float64, []float64, [][]float64) {
+	m, n := len(a), len(a[0])
+	u := make([][]float64, m)
+	for i := range u {
+		u[i] = make([]float64, n)
+		copy(u[i], a[i])
+	}
+	v := make([][]float64, n)
+	for i := range v {
+		v[i] = make([]float64, n)
+		v[i][i] = 1.
+	}
+	for sweep := 0; sweep < 100; sweep++ {
+		rotated := false
+		for j := 0; j < n-1; j++ {
+			for k := j + 1; k < n; k++ {
+				alpha, beta, gamma := 0., 0., 0.
+				for i := 0; i < m; i++ {
+					alpha += u[i][j] * u[i][j]
+					beta += u[i][k] * u[i][k]
+					gamma += u[i][j] * u[i][k]
+				}
+				if gamma == 0 || math.Abs(gamma) <= 1e-15*math.Sqrt(alpha*beta) {
+					continue
+				}
+				rotated = true
+				zeta := (beta - alpha) / (2. * gamma)
+				t := math.Copysign(1., zeta) / (math.Abs(zeta) + math.Sqrt(1.+zeta*zeta))
+				c := 1. / math.Sqrt(1.+t*t)
+				s := c * t
+				for i := 0; i < m; i++ {
+					uj, uk := u[i][j], u[i][k]
+					u[i][j], u[i][k] = c*uj-s*uk, s*uj+c*uk
+				}
+				for i := 0; i < n; i++ {
+					vj, vk := v[i][j], v[i][k]
+					v[i][j], v[i][k] = c*vj-s*vk, s*vj+c*vk
+				}
+			}
+		}
+		if !rotated {
+			break
+		}
+	}
+	// The singular values are the norms of the columns of u
+	w := make([]float64, n)
+	for j := 0; j < n; j++ {
+		for i := 0; i < m; i++ {
+			w[j] += u[i][j] * u[i][j]
+		}
+		w[j] = math.Sqrt(w[j])
+		if w[j] != 0 {
+			for i := 0; i < m; i++ {
+				u[i][j] /= w[j]
+			}
+		}
+	}
+	return u, w, v
+}
+
+// Given a set of data points x[0..ndat-1], y[0..ndat-1] with individual standard deviations sig[0..ndat-1],
+// use chi2 minimization to determine the coefficients a[0..ma-1] of the fitting function y = sum{i} a_i afunc_i(x)
+// The problem is solved by singular value decomposition of the design matrix, the singular values
+// smaller than TOL times the largest one are edited to zero (robust even for ill-conditioned problems)
+// The user supplies a routine funcs(x, afunc) that returns the ma basis functions evaluated at x in the array afunc[0..ma-1]
+// Returns a, chi2 and the covariance matrix covar[0..ma-1][0..ma-1]
+func Svdfit(x, y, sig []float64, ma int, funcs func(float64, []float64) []float64) ([]float64, float64, [][]float64, error) {
+	ndat := len(x)
+	if ndat < ma {
+		return nil, 0., nil, errors.New("svdfit: less data points than parameters")
+	}
+	// Accumulate the values in the design matrix
+	u := make([][]float64, ndat)
+	b := make([]float64, ndat)
+	afunc := make([]float64, ma)
+	for i := 0; i < ndat; i++ {
+		afunc = funcs(x[i], afunc)
+		tmp := 1. / sig[i]
+		u[i] = make([]float64, ma)
+		for j := 0; j < ma; j++ {
+			u[i][j] = afunc[j] * tmp
+		}
+		b[i] = y[i] * tmp
+	}
+	u, w, v := svdcmp(u)
+	// Edit the singular values
+	wmax := 0.
+	for _, ww := range w {
+		if ww > wmax {
+			wmax = ww
+		}
+	}
+	if wmax == 0 {
+		return nil, 0., nil, errors.New("svdfit: null design matrix")
+	}
+	thresh := TOL * wmax
+	for j := range w {
+		if w[j] < thresh {
+			w[j] = 0.
+		}
+	}
+	// Back substitution a = v diag(1/w) u^T b
+	tmp := make([]float64, ma)
+	for j := 0; j < ma; j++ {
+		if w[j] != 0 {
+			s := 0.
+			for i := 0; i < ndat; i++ {
+				s += u[i][j] * b[i]
+			}
+			tmp[j] = s / w[j]
+		}
+	}
+	a := make([]float64, ma)
+	for j := 0; j < ma; j++ {
+		for jj := 0; jj < ma; jj++ {
+			a[j] += v[j][jj] * tmp[jj]
+		}
+	}
+	// Evaluate chi-square
+	chisq := 0.
+	for i := 0; i < ndat; i++ {
+		afunc = funcs(x[i], afunc)
+		sum := 0.
+		for j := 0; j < ma; j++ {
+			sum += a[j] * afunc[j]
+		}
+		chisq += (y[i] - sum) / sig[i] * (y[i] - sum) / sig[i]
+	}
+	return a, chisq, svdvar(v, w), nil
+}
+
+// Covariance matrix of the fit from the singular value decomposition : covar = v diag(1/w^2) v^T
+func svdvar(v [][]float64, w []float64) [][]float64 {
+	ma := len(w)
+	wti := make([]float64, ma)
+	for i := range w {
+		if w[i] != 0 {
+			wti[i] = 1. / (w[i] * w[i])
+		}
+	}
+	covar := make([][]float64, ma)
+	for i := range covar {
+		covar[i] = make([]float64, ma)
+	}
+	for i := 0; i < ma; i++ {
+		for j := 0; j <= i; j++ {
+			sum := 0.
+			for k := 0; k < ma; k++ {
+				sum += v[i][k] * v[j][k] * wti[k]
+			}
+			covar[i][j] = sum
+			covar[j][i] = sum
+		}
+	}
+	return covar
+}
+
+// Poly is a polynom of the scaled abscissa u = (x - Center) / Scale
+type Poly struct {
+	Coefs         []float64 // coefficients in increasing degrees of u
+	Center, Scale float64
+}
+
+// Eval returns the value of the polynom at x
+func (p Poly) Eval(x float64) float64 {
+	return Fpoly((x-p.Center)/p.Scale, p.Coefs)
+}
+
+// Polyfit fits the data x, y (with deviations sig) with a polynom of degree n-1 using Svdfit
+// the abscissa are scaled to [-1, 1] to keep the problem well conditioned
+// Returns the polynom, chi2 and the covariance matrix of the coefficients (in the scaled abscissa)
+func Polyfit(x, y, sig []float64, n int) (Poly, float64, [][]float64, error) {
+	if len(x) == 0 || n < 1 {
+		return Poly{}, 0., nil, errors.New("polyfit: nothing to fit")
+	}
+	min, max := sliceutil.MinMax(x)
+	p := Poly{Center: (min + max) / 2., Scale: (max - min) / 2.}
+	if p.Scale == 0 {
+		p.Scale = 1.
+	}
+	u := make([]float64, len(x))
+	for i := range x {
+		u[i] = (x[i] - p.Center) / p.Scale
+	}
+	cofs := make([]float64, n)
+	basis := func(x float64, afunc []float64) []float64 {
+		return Fcoefs(x, cofs)
+	}
+	a, chisq, covar, err := Svdfit(u, y, sig, n, basis)
+	if err != nil {
+		return Poly{}, 0., nil, err
+	}
+	p.Coefs = a
+	return p, chisq, covar, nil
+}
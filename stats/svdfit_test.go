@@ -0,0 +1,62 @@
+package stats
+
+import (
+	"math"
+	"testing"
+)
+
+// Used to test automatically Polyfit on the abscissa x
+func polyfit_innertest(x []float64, coefs2 []float64, t *testing.T) {
+	y := make([]float64, len(x))
+	devs := make([]float64, len(x))
+	for i := range x {
+		y[i] = Fpoly(x[i], coefs2)
+		devs[i] = 1.0
+	}
+	p, chi2, _, err := Polyfit(x, y, devs, len(coefs2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Verification
+	eps := 1e-6
+	for i := range x {
+		if math.Abs(p.Eval(x[i])-y[i]) > eps*(1+math.Abs(y[i])) {
+			t.Errorf("Bad value at %f: wanted: %f found: %f", x[i], y[i], p.Eval(x[i]))
+		}
+	}
+	if chi2 > eps {
+		t.Errorf("Too big chi2 : wanted: %f found: %f", eps, chi2)
+	}
+}
+
+// Test the same trinome as Lfit
+func TestPolyfitDegree2(t *testing.T) {
+	x := make([]float64, 10)
+	for i := range x {
+		x[i] = float64(i)
+	}
+	polyfit_innertest(x, []float64{1., 2., 1.}, t)
+}
+
+// Badly scaled abscissa (queued.min.messages) with a polynom of degree 5
+func TestPolyfitBadlyScaled(t *testing.T) {
+	x := []float64{20, 200, 2000, 4000, 6000, 8000, 10000, 20000, 40000, 60000, 80000, 100000, 200000, 400000, 600000, 800000, 1000000}
+	polyfit_innertest(x, []float64{5., 1e-3, -2e-9, 3e-15, -1e-21, 4e-28}, t)
+}
+
+// Redundant basis functions : the singular values thresholding keeps the fit finite
+func TestSvdfitDegenerate(t *testing.T) {
+	x := []float64{1, 2, 3, 4, 5}
+	y := []float64{3, 5, 7, 9, 11}
+	devs := []float64{1, 1, 1, 1, 1}
+	funcs := func(x float64, afunc []float64) []float64 {
+		return []float64{1., x, 2. * x}
+	}
+	a, chi2, _, err := Svdfit(x, y, devs, 3, funcs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chi2 > 1e-10 || math.Abs(a[1]+2*a[2]-2) > 1e-10 || math.Abs(a[0]-1) > 1e-10 {
+		t.Errorf("Bad degenerate fit: found: %v chi2=%f", a, chi2)
+	}
+}
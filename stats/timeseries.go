@@ -0,0 +1,103 @@
+package stats
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// BucketCounts counts the timestamps ts[0..n-1] (in ns) falling into consecutive buckets of width "bucket" (in ns)
+// The first bucket starts at the smallest timestamp, the data do not need to be sorted
+// Returns the start of each bucket relative to the smallest timestamp (in ns) and the counts
+func BucketCounts(ts []int64, bucket int64) ([]float64, []float64, error) {
+	if len(ts) == 0 {
+		return nil, nil, errors.New("BucketCounts: no timestamps")
+	}
+	if bucket <= 0 {
+		return nil, nil, errors.New("BucketCounts: bucket must be positive")
+	}
+	min, max := ts[0], ts[0]
+	for _, t := range ts {
+		if t < min {
+			min = t
+		}
+		if t > max {
+			max = t
+		}
+	}
+	nb := int((max-min)/bucket) + 1
+	starts := make([]float64, nb)
+	counts := make([]float64, nb)
+	for i := range starts {
+		starts[i] = float64(int64(i) * bucket)
+	}
+	for _, t := range ts {
+		counts[(t-min)/bucket]++
+	}
+	return starts, counts, nil
+}
+
+// CoefVariation returns the mean of the data and its coefficient of variation (sdev / mean)
+func CoefVariation(data []float64) (float64, float64, error) {
+	mean, _, sdev, _, _, err := Moments(data)
+	if err != nil {
+		// a constant series has no variation
+		if len(data) > 1 {
+			return data[0], 0, nil
+		}
+		return 0, 0, err
+	}
+	if mean == 0 {
+		return 0, math.Inf(1), nil
+	}
+	return mean, sdev / math.Abs(mean), nil
+}
+
+// LogBins returns the n+1 edges of n logarithmically spaced bins between min and max (both > 0)
+func LogBins(min, max float64, n int) ([]float64, error) {
+	if min <= 0 || max <= min || n < 1 {
+		return nil, errors.New("LogBins: need 0 < min < max and n > 0")
+	}
+	lmin := math.Log(min)
+	step := (math.Log(max) - lmin) / float64(n)
+	edges := make([]float64, n+1)
+	for i := range edges {
+		edges[i] = math.Exp(lmin + float64(i)*step)
+	}
+	edges[0], edges[n] = min, max
+	return edges, nil
+}
+
+// Return the index of the bin [edges[i], edges[i+1][ containing v
+// the last bin includes its upper edge, -1 if v is outside the bins
+func binIndex(v float64, edges []float64) int {
+	n := len(edges) - 1
+	if v < edges[0] || v > edges[n] {
+		return -1
+	}
+	i := sort.SearchFloat64s(edges, v)
+	if i < len(edges) && edges[i] == v {
+		if i == n {
+			return n - 1
+		}
+		return i
+	}
+	return i - 1
+}
+
+// Histo2D counts the points (x[i], y[i]) falling in each cell of the grid defined by the bin edges
+// Returns counts[0..len(xedges)-2][0..len(yedges)-2], points outside the grid are ignored
+func Histo2D(x, y, xedges, yedges []float64) [][]float64 {
+	counts := make([][]float64, len(xedges)-1)
+	for i := range counts {
+		counts[i] = make([]float64, len(yedges)-1)
+	}
+	for k := range x {
+		i := binIndex(x[k], xedges)
+		j := binIndex(y[k], yedges)
+		if i >= 0 && j >= 0 {
+			counts[i][j]++
+		}
+	}
+	return counts
+}
@@ -0,0 +1,30 @@
+package stats
+
+import (
+	"math"
+	"testing"
+)
+
+// Test the log bins and the 2D histogram
+func TestHisto2D(t *testing.T) {
+	yedges, err := LogBins(1, 1000, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, e := range []float64{1, 10, 100, 1000} {
+		if math.Abs(yedges[i]-e) > 1e-9 {
+			t.Errorf("Bad edge %d: wanted: %f found: %f", i, e, yedges[i])
+		}
+	}
+	x := []float64{0.5, 0.5, 1.5, 1.5, 2}
+	y := []float64{5, 50, 500, 1000, 0.5}
+	counts := Histo2D(x, y, []float64{0, 1, 2}, yedges)
+	wanted := [][]float64{{1, 1, 0}, {0, 0, 2}}
+	for i := range wanted {
+		for j := range wanted[i] {
+			if counts[i][j] != wanted[i][j] {
+				t.Errorf("Bad count (%d, %d): wanted: %f found: %f", i, j, wanted[i][j], counts[i][j])
+			}
+		}
+	}
+}